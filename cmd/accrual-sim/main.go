@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"gophermart/internal/accrualsim"
+	"gophermart/internal/logger"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultRegisteredFor = time.Second
+	defaultProcessingFor = 3 * time.Second
+	defaultRetryAfter    = time.Minute
+	shutdownTimeout      = 5 * time.Second
+	readHeaderTimeout    = 5 * time.Second
+)
+
+func main() {
+	loggerZap, err := logger.InitilazerLogger(logger.FormatConsole)
+	if err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	if err = run(loggerZap); err != nil {
+		loggerZap.Fatal("Accrual simulator failed", zap.Error(err))
+	}
+}
+
+func run(loggerZap *zap.SugaredLogger) error {
+	address := flag.String("a", "localhost:8080", "адрес и порт запуска имитатора")
+	rulesPath := flag.String("rules", "", "JSON-файл с механиками вознаграждения в формате POST /api/goods")
+	registeredFor := flag.Duration("registered-for", defaultRegisteredFor, "сколько заказ остаётся в REGISTERED")
+	processingFor := flag.Duration("processing-for", defaultProcessingFor, "сколько заказ остаётся в PROCESSING")
+	requestsPerMinute := flag.Int("rpm", 0, "лимит запросов статуса в минуту, 0 — без ограничения")
+	tooManyRequestsRate := flag.Float64("fail-429", 0, "доля запросов статуса, на которые отвечаем 429")
+	serverErrorRate := flag.Float64("fail-500", 0, "доля запросов статуса, на которые отвечаем 500")
+	retryAfter := flag.Duration("retry-after", defaultRetryAfter, "Retry-After для случайных ответов 429")
+	flag.Parse()
+
+	if envAddress, ok := os.LookupEnv("RUN_ADDRESS"); ok {
+		*address = envAddress
+	}
+	rules, err := readRules(*rulesPath)
+	if err != nil {
+		return err
+	}
+
+	sim, err := accrualsim.New(accrualsim.Config{
+		Rules:               rules,
+		RegisteredFor:       *registeredFor,
+		ProcessingFor:       *processingFor,
+		RetryAfter:          *retryAfter,
+		RequestsPerMinute:   *requestsPerMinute,
+		TooManyRequestsRate: *tooManyRequestsRate,
+		ServerErrorRate:     *serverErrorRate,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to configure simulator: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{
+		Addr:              *address,
+		Handler:           sim.Handler(),
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		loggerZap.Infoln("Accrual simulator listening on", *address)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err = <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("failed to listen: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+	defer cancel()
+	if err = server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shutdown: %w", err)
+	}
+	loggerZap.Infoln("Accrual simulator stopped")
+	return nil
+}
+
+func readRules(path string) ([]accrualsim.Rule, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules: %w", err)
+	}
+	var rules []accrualsim.Rule
+	if err = json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse rules %s: %w", path, err)
+	}
+	return rules, nil
+}
@@ -2,19 +2,27 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"gophermart/internal/app/command"
+	"gophermart/internal/app/repositories"
 	"gophermart/internal/config"
 	"gophermart/internal/logger"
+	"gophermart/internal/metrics"
 	"gophermart/internal/server"
 	"gophermart/internal/store"
+	"gophermart/internal/tracing"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"go.uber.org/zap"
 )
 
 func main() {
-	loggerZap, err := logger.InitilazerLogger()
+	// формат логов известен только после разбора конфигурации, до этого пишем для разработки
+	loggerZap, err := logger.InitilazerLogger(logger.FormatConsole)
 	if err != nil {
 		log.Fatalf("Failed to initialize logger: %v", err)
 	}
@@ -30,20 +38,99 @@ func run(loggerZap *zap.SugaredLogger) error {
 		loggerZap.Info(err.Error(), "failed to parse flags")
 		return fmt.Errorf("failed to parse flags: %w", err)
 	}
+	if cfg.PrintConfig {
+		return cfg.Print(os.Stdout)
+	}
+	if err = logger.SetLevel(cfg.LogLevel); err != nil {
+		return fmt.Errorf("failed to set log level: %w", err)
+	}
+	if loggerZap, err = logger.InitilazerLogger(cfg.LogFormat); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	defer func() {
+		_ = loggerZap.Sync()
+	}()
+	if cfg.AuthSecretGenerated && cfg.AuthJwtAlgorithm == "HS256" {
+		loggerZap.Warnln("Auth secret is not configured, using a random one: " +
+			"tokens will not survive a restart or work across instances")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	defer func() {
+		// ctx уже отменён: отправляем накопленные span с отдельным таймаутом
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			loggerZap.Errorln("Failed to flush traces:", err)
+		}
+	}()
+
+	reloader := config.NewReloader(cfg, config.LoadFromCommandLine)
+	reloader.Subscribe(func(cfg *config.Config) {
+		// значение уже проверено при разборе конфигурации
+		_ = logger.SetLevel(cfg.LogLevel)
+	})
+	go reloadOnSighup(ctx, reloader, loggerZap)
 
-	ctx := context.Background()
 	storeDB, err := store.NewDB(ctx, cfg.DatabaseDsn)
 	if err != nil {
 		return fmt.Errorf("database error: %w", err)
 	}
+	defer storeDB.Close()
 
+	err = metrics.Register(
+		metrics.NewPoolCollector(storeDB.Pool),
+		metrics.NewQueueCollector(repositories.NewJobRepository(storeDB.Pool).CountJobs),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register metrics: %w", err)
+	}
+
+	if err = command.ReconcileBalances(ctx, storeDB.Pool, loggerZap); err != nil {
+		loggerZap.Errorln("Balance reconciliation failed:", err)
+	}
+
+	agentErr := make(chan error, 1)
 	go func() {
-		if err = command.ConfigureSendOrderHandler(storeDB.Pool, cfg, loggerZap); err != nil {
-			log.Panicln(err)
+		err := command.ConfigureSendOrderHandler(ctx, storeDB.Pool, cfg, reloader, loggerZap)
+		if err != nil {
+			stop()
 		}
+		agentErr <- err
 	}()
-	if err = server.ConfigureServerHandler(storeDB.Pool, cfg, loggerZap); err != nil {
-		log.Panicln(err)
+
+	serverErr := server.ConfigureServerHandler(ctx, storeDB.Pool, cfg, reloader, loggerZap)
+	stop()
+	if err = errors.Join(serverErr, <-agentErr); err != nil {
+		return fmt.Errorf("shutdown with error: %w", err)
 	}
+	loggerZap.Infoln("Application stopped")
 	return nil
 }
+
+// reloadOnSighup перечитывает конфигурацию по SIGHUP до остановки сервиса.
+func reloadOnSighup(ctx context.Context, reloader *config.Reloader, loggerZap *zap.SugaredLogger) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			result, err := reloader.Reload()
+			if err != nil {
+				loggerZap.Errorln("Config reload rejected:", err)
+				continue
+			}
+			loggerZap.Infow("Config reloaded", "applied", result.Applied, "restart_required", result.RestartRequired)
+		}
+	}
+}
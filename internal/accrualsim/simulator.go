@@ -0,0 +1,261 @@
+// Package accrualsim — имитация системы расчёта начислений для локального запуска и тестов.
+package accrualsim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gophermart/internal/app/money"
+	"gophermart/internal/app/utils"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	RewardPercent = "%"
+	RewardPoints  = "pt"
+
+	StatusRegistered = "REGISTERED"
+	StatusProcessing = "PROCESSING"
+	StatusInvalid    = "INVALID"
+	StatusProcessed  = "PROCESSED"
+
+	percentScale = 100 * 100 // проценты и цена хранятся в сотых долях
+)
+
+var (
+	ErrInvalidRule   = errors.New("invalid reward rule")
+	ErrDuplicateRule = errors.New("reward rule already registered")
+)
+
+// Rule — механика вознаграждения: товары, в описании которых встречается Match, приносят Reward
+// процентов от цены (RewardPercent) или фиксированное число баллов (RewardPoints).
+type Rule struct {
+	Match      string      `json:"match"`
+	RewardType string      `json:"reward_type"`
+	Reward     money.Money `json:"reward"`
+}
+
+type Good struct {
+	Description string      `json:"description"`
+	Price       money.Money `json:"price"`
+}
+
+type OrderRequest struct {
+	Order string `json:"order"`
+	Goods []Good `json:"goods"`
+}
+
+type OrderResponse struct {
+	Accrual *money.Money `json:"accrual,omitempty"`
+	Order   string       `json:"order"`
+	Status  string       `json:"status"`
+}
+
+type Config struct {
+	// Now и Random подменяются в тестах; по умолчанию time.Now и rand.Float64.
+	Now    func() time.Time
+	Random func() float64
+	Rules  []Rule
+	// RegisteredFor — сколько заказ остаётся в REGISTERED, ProcessingFor — сколько затем в PROCESSING.
+	RegisteredFor time.Duration
+	ProcessingFor time.Duration
+	RetryAfter    time.Duration
+	// RequestsPerMinute ограничивает GET /api/orders/{number}; 0 — без ограничения.
+	RequestsPerMinute int
+	// TooManyRequestsRate и ServerErrorRate — доля запросов, на которые отвечаем 429 и 500.
+	TooManyRequestsRate float64
+	ServerErrorRate     float64
+}
+
+type order struct {
+	registeredAt time.Time
+	goods        []Good
+}
+
+type Simulator struct {
+	windowStart time.Time
+	orders      map[string]*order
+	cfg         Config
+	rules       []Rule
+	windowCount int
+	mu          sync.Mutex
+}
+
+func New(cfg Config) (*Simulator, error) {
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	if cfg.Random == nil {
+		cfg.Random = rand.Float64
+	}
+	if cfg.RetryAfter <= 0 {
+		cfg.RetryAfter = time.Minute
+	}
+	s := &Simulator{
+		cfg:    cfg,
+		orders: make(map[string]*order),
+	}
+	for _, rule := range cfg.Rules {
+		if err := s.AddRule(rule); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *Simulator) AddRule(rule Rule) error {
+	if rule.Match == "" || rule.Reward <= 0 || (rule.RewardType != RewardPercent && rule.RewardType != RewardPoints) {
+		return fmt.Errorf("%w: %+v", ErrInvalidRule, rule)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.rules {
+		if existing.Match == rule.Match {
+			return fmt.Errorf("%w: %q", ErrDuplicateRule, rule.Match)
+		}
+	}
+	s.rules = append(s.rules, rule)
+	return nil
+}
+
+func (s *Simulator) Handler() http.Handler {
+	router := chi.NewRouter()
+	router.Post("/api/goods", s.registerRule)
+	router.Post("/api/orders", s.registerOrder)
+	router.Get("/api/orders/{number}", s.getOrder)
+	return router
+}
+
+func (s *Simulator) registerRule(w http.ResponseWriter, r *http.Request) {
+	var rule Rule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	err := s.AddRule(rule)
+	switch {
+	case errors.Is(err, ErrDuplicateRule):
+		http.Error(w, "", http.StatusConflict)
+	case err != nil:
+		http.Error(w, "", http.StatusBadRequest)
+	default:
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (s *Simulator) registerOrder(w http.ResponseWriter, r *http.Request) {
+	var req OrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !validOrderNumber(req.Order) {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.orders[req.Order]; ok {
+		http.Error(w, "", http.StatusConflict)
+		return
+	}
+	s.orders[req.Order] = &order{registeredAt: s.cfg.Now(), goods: req.Goods}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Simulator) getOrder(w http.ResponseWriter, r *http.Request) {
+	number := chi.URLParam(r, "number")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if retryAfter, limited := s.rateLimited(); limited {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = fmt.Fprintf(w, "No more than %d requests per minute allowed", s.cfg.RequestsPerMinute)
+		return
+	}
+	if s.cfg.Random() < s.cfg.TooManyRequestsRate {
+		w.Header().Set("Retry-After", strconv.Itoa(int(s.cfg.RetryAfter.Seconds())))
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+	if s.cfg.Random() < s.cfg.ServerErrorRate {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	registered, ok := s.orders[number]
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s.status(number, registered))
+}
+
+// rateLimited считает запросы в окне длиной в минуту; вызывается под s.mu.
+func (s *Simulator) rateLimited() (time.Duration, bool) {
+	if s.cfg.RequestsPerMinute <= 0 {
+		return 0, false
+	}
+	now := s.cfg.Now()
+	if now.Sub(s.windowStart) >= time.Minute {
+		s.windowStart = now
+		s.windowCount = 0
+	}
+	if s.windowCount >= s.cfg.RequestsPerMinute {
+		return s.windowStart.Add(time.Minute).Sub(now).Round(time.Second), true
+	}
+	s.windowCount++
+	return 0, false
+}
+
+// status вычисляет состояние заказа по времени с момента регистрации; вызывается под s.mu.
+func (s *Simulator) status(number string, registered *order) OrderResponse {
+	elapsed := s.cfg.Now().Sub(registered.registeredAt)
+	switch {
+	case elapsed < s.cfg.RegisteredFor:
+		return OrderResponse{Order: number, Status: StatusRegistered}
+	case elapsed < s.cfg.RegisteredFor+s.cfg.ProcessingFor:
+		return OrderResponse{Order: number, Status: StatusProcessing}
+	}
+
+	accrual, matched := s.calculate(registered.goods)
+	if !matched {
+		// ни один товар не подпадает под механики вознаграждения
+		return OrderResponse{Order: number, Status: StatusInvalid}
+	}
+	return OrderResponse{Order: number, Status: StatusProcessed, Accrual: &accrual}
+}
+
+// calculate применяет к каждому товару первую подходящую механику.
+func (s *Simulator) calculate(goods []Good) (money.Money, bool) {
+	var total money.Money
+	matched := false
+	for _, good := range goods {
+		for _, rule := range s.rules {
+			if !strings.Contains(good.Description, rule.Match) {
+				continue
+			}
+			matched = true
+			if rule.RewardType == RewardPoints {
+				total += rule.Reward
+			} else {
+				// округление половиной вверх до сотых
+				total += money.FromMinorUnits((good.Price.MinorUnits()*rule.Reward.MinorUnits() + percentScale/2) / percentScale)
+			}
+			break
+		}
+	}
+	return total, matched
+}
+
+func validOrderNumber(number string) bool {
+	parsed, err := strconv.ParseInt(number, 10, 64)
+	return err == nil && parsed > 0 && utils.LuhnCheck(parsed)
+}
@@ -0,0 +1,162 @@
+package accrualsim
+
+import (
+	"encoding/json"
+	"gophermart/internal/app/money"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func newTestSimulator(t *testing.T, cfg Config) (*Simulator, *clock) {
+	t.Helper()
+	c := &clock{now: time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)}
+	cfg.Now = c.Now
+	if cfg.Random == nil {
+		cfg.Random = func() float64 { return 1 }
+	}
+	sim, err := New(cfg)
+	require.NoError(t, err)
+	return sim, c
+}
+
+func do(t *testing.T, handler http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return recorder
+}
+
+func getOrder(t *testing.T, handler http.Handler, number string) OrderResponse {
+	t.Helper()
+	recorder := do(t, handler, http.MethodGet, "/api/orders/"+number, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var response OrderResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	return response
+}
+
+func TestSimulator_OrderProgressesToProcessed(t *testing.T) {
+	sim, c := newTestSimulator(t, Config{RegisteredFor: time.Second, ProcessingFor: 2 * time.Second})
+	handler := sim.Handler()
+
+	assert.Equal(t, http.StatusOK, do(t, handler, http.MethodPost, "/api/goods",
+		`{"match": "Bork", "reward": 10, "reward_type": "%"}`).Code)
+	assert.Equal(t, http.StatusOK, do(t, handler, http.MethodPost, "/api/goods",
+		`{"match": "LG", "reward": 5.5, "reward_type": "pt"}`).Code)
+	assert.Equal(t, http.StatusAccepted, do(t, handler, http.MethodPost, "/api/orders",
+		`{"order": "12345678903", "goods": [
+			{"description": "Чайник Bork", "price": 7299.85},
+			{"description": "Телевизор LG", "price": 50000},
+			{"description": "Стакан", "price": 100}
+		]}`).Code)
+
+	assert.Equal(t, StatusRegistered, getOrder(t, handler, "12345678903").Status)
+	c.now = c.now.Add(time.Second)
+	assert.Equal(t, StatusProcessing, getOrder(t, handler, "12345678903").Status)
+	c.now = c.now.Add(2 * time.Second)
+
+	response := getOrder(t, handler, "12345678903")
+	assert.Equal(t, StatusProcessed, response.Status)
+	require.NotNil(t, response.Accrual)
+	// 10% от 7299.85 = 729.985 → 729.99, плюс 5.5 баллов
+	assert.Equal(t, money.FromMinorUnits(73549), *response.Accrual)
+}
+
+func TestSimulator_OrderWithoutRewardsIsInvalid(t *testing.T) {
+	sim, _ := newTestSimulator(t, Config{})
+	handler := sim.Handler()
+
+	require.Equal(t, http.StatusAccepted, do(t, handler, http.MethodPost, "/api/orders",
+		`{"order": "12345678903", "goods": [{"description": "Стакан", "price": 100}]}`).Code)
+
+	response := getOrder(t, handler, "12345678903")
+	assert.Equal(t, StatusInvalid, response.Status)
+	assert.Nil(t, response.Accrual)
+}
+
+func TestSimulator_RegistrationErrors(t *testing.T) {
+	sim, _ := newTestSimulator(t, Config{Rules: []Rule{{Match: "Bork", Reward: 1000, RewardType: RewardPercent}}})
+	handler := sim.Handler()
+
+	tests := []struct {
+		name     string
+		path     string
+		body     string
+		expected int
+	}{
+		{name: "duplicate rule", path: "/api/goods", body: `{"match": "Bork", "reward": 5, "reward_type": "pt"}`,
+			expected: http.StatusConflict},
+		{name: "unknown reward type", path: "/api/goods", body: `{"match": "LG", "reward": 5, "reward_type": "x"}`,
+			expected: http.StatusBadRequest},
+		{name: "malformed rule", path: "/api/goods", body: `{`, expected: http.StatusBadRequest},
+		{name: "luhn failure", path: "/api/orders", body: `{"order": "12345678901", "goods": []}`,
+			expected: http.StatusBadRequest},
+		{name: "new order", path: "/api/orders", body: `{"order": "12345678903", "goods": []}`,
+			expected: http.StatusAccepted},
+		{name: "duplicate order", path: "/api/orders", body: `{"order": "12345678903", "goods": []}`,
+			expected: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, do(t, handler, http.MethodPost, tt.path, tt.body).Code)
+		})
+	}
+}
+
+func TestSimulator_UnknownOrderHasNoContent(t *testing.T) {
+	sim, _ := newTestSimulator(t, Config{})
+	assert.Equal(t, http.StatusNoContent, do(t, sim.Handler(), http.MethodGet, "/api/orders/79927398713", "").Code)
+}
+
+func TestSimulator_RequestsPerMinute(t *testing.T) {
+	sim, c := newTestSimulator(t, Config{RequestsPerMinute: 2})
+	handler := sim.Handler()
+
+	for range 2 {
+		assert.Equal(t, http.StatusNoContent, do(t, handler, http.MethodGet, "/api/orders/79927398713", "").Code)
+	}
+	c.now = c.now.Add(20 * time.Second)
+	limited := do(t, handler, http.MethodGet, "/api/orders/79927398713", "")
+	assert.Equal(t, http.StatusTooManyRequests, limited.Code)
+	assert.Equal(t, "40", limited.Header().Get("Retry-After"))
+	assert.Equal(t, "No more than 2 requests per minute allowed", limited.Body.String())
+
+	c.now = c.now.Add(40 * time.Second)
+	assert.Equal(t, http.StatusNoContent, do(t, handler, http.MethodGet, "/api/orders/79927398713", "").Code)
+}
+
+func TestSimulator_InjectedFailures(t *testing.T) {
+	rolls := []float64{0.05, 0.5, 0.05, 0.5, 0.5}
+	sim, _ := newTestSimulator(t, Config{
+		TooManyRequestsRate: 0.1,
+		ServerErrorRate:     0.1,
+		RetryAfter:          5 * time.Second,
+		Random: func() float64 {
+			roll := rolls[0]
+			rolls = rolls[1:]
+			return roll
+		},
+	})
+	handler := sim.Handler()
+
+	tooMany := do(t, handler, http.MethodGet, "/api/orders/79927398713", "")
+	assert.Equal(t, http.StatusTooManyRequests, tooMany.Code)
+	assert.Equal(t, "5", tooMany.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusInternalServerError, do(t, handler, http.MethodGet, "/api/orders/79927398713", "").Code)
+	assert.Equal(t, http.StatusNoContent, do(t, handler, http.MethodGet, "/api/orders/79927398713", "").Code)
+}
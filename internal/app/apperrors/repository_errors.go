@@ -7,4 +7,10 @@ var ErrDuplicateOrderIDAnotherUserID = errors.New("duplicate order ID with anoth
 var ErrDuplicateLogin = errors.New("duplicate login")
 
 var ErrBalanceNotEnought = errors.New("balance Not Enought")
+var ErrInvalidWithdrawSum = errors.New("withdraw sum must be positive")
 var ErrOrderNotFound = errors.New("order not found")
+
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
+var ErrJobLeaseLost = errors.New("job lease lost")
@@ -0,0 +1,41 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"gophermart/internal/app/repositories"
+	"gophermart/internal/app/services"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+func ReconcileBalances(
+	ctx context.Context,
+	db *pgxpool.Pool,
+	logger *zap.SugaredLogger,
+) error {
+	balanceService := services.NewBalanceService(
+		db,
+		repositories.NewUserRepository(db),
+		repositories.NewOrderRepository(db),
+		repositories.NewWithdrawRepository(db),
+		repositories.NewBalanceEntryRepository(db),
+	)
+	mismatches, err := balanceService.Reconcile(ctx)
+	if err != nil {
+		return fmt.Errorf("failed reconcile balances: %w", err)
+	}
+	for _, mismatch := range mismatches {
+		logger.Warnw("Cached balance does not match ledger",
+			"user_id", mismatch.UserID,
+			"cached", mismatch.Cached,
+			"ledger", mismatch.Ledger,
+		)
+	}
+	if len(mismatches) == 0 {
+		logger.Infoln("Balance reconciliation passed: cached balances match the ledger")
+	}
+
+	return nil
+}
@@ -1,38 +1,65 @@
 package command
 
 import (
+	"context"
 	"fmt"
 	"gophermart/internal/app/handlers"
 	"gophermart/internal/app/repositories"
 	"gophermart/internal/app/services"
 	"gophermart/internal/app/services/accrual"
 	"gophermart/internal/config"
+	"gophermart/internal/metrics"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
 )
 
 func ConfigureSendOrderHandler(
+	ctx context.Context,
 	db *pgxpool.Pool,
 	cfg *config.Config,
+	reloader *config.Reloader,
 	logger *zap.SugaredLogger,
 ) error {
-	client := accrual.NewClient(cfg.AccrualAddress, cfg.AgentTimeoutClient)
+	gate := accrual.NewGate(cfg.AccrualRequestsPerMinute)
+	breaker := accrual.NewBreaker(accrual.BreakerConfig{
+		FailureThreshold: cfg.AccrualBreakerFailures,
+		OpenTimeout:      cfg.AccrualBreakerOpen,
+		HalfOpenRequests: cfg.AccrualBreakerHalfOpen,
+	})
+	breaker.OnStateChange(func(from, to accrual.BreakerState) {
+		logger.Warnw("accrual circuit breaker state changed", "from", from.String(), "to", to.String())
+	})
+	client := accrual.WithBreaker(accrual.NewClient(cfg.AccrualAddress, cfg.AgentTimeoutClient, gate), breaker)
+	err := metrics.Register(
+		metrics.NewRetryAfterGauge(gate.PausedUntil),
+		metrics.NewBreakerStateGauge(func() int { return int(breaker.State()) }),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register accrual agent metrics: %w", err)
+	}
 	orderRepository := repositories.NewOrderRepository(db)
 	userRepository := repositories.NewUserRepository(db)
 	jobRepository := repositories.NewJobRepository(db)
+	balanceEntryRepository := repositories.NewBalanceEntryRepository(db)
 	sendOrdersService := services.NewAccrualService(
 		db,
 		jobRepository,
 		orderRepository,
 		userRepository,
+		balanceEntryRepository,
 		client,
 		cfg,
 		logger,
 	)
 	sendOrderHandler := handlers.NewSendOrderHandler(sendOrdersService, cfg, logger)
+	reloader.Subscribe(sendOrderHandler.Reconfigure)
+	reloader.Subscribe(func(cfg *config.Config) {
+		// перечитывание возвращает настроенный лимит, даже если система начислений его ужесточила
+		gate.SetRate(cfg.AccrualRequestsPerMinute)
+	})
 	logger.Infoln("Start accrual agent interval:", cfg.PollInterval)
-	err := sendOrderHandler.SendUserOrders()
+	err = sendOrderHandler.SendUserOrders(ctx)
 	if err != nil {
 		return fmt.Errorf("failed send user orders: %w", err)
 	}
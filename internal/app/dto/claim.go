@@ -6,5 +6,6 @@ import (
 
 type Claims struct {
 	jwt.RegisteredClaims
-	UserID int
+	UserID    int
+	SessionID int64
 }
@@ -1,6 +1,8 @@
 package dto
 
+import "gophermart/internal/app/money"
+
 type BalanceResponseBody struct {
-	Current   float64 `json:"current"`
-	Withdrawn float64 `json:"withdrawn"`
+	Current   money.Money `json:"current"`
+	Withdrawn money.Money `json:"withdrawn"`
 }
@@ -1,8 +1,10 @@
 package dto
 
+import "gophermart/internal/app/money"
+
 type OrdersResponseBody struct {
-	Number     string   `json:"number"`
-	Status     string   `json:"status"`
-	Accrual    *float64 `json:"accrual,omitempty"`
-	UploadedAt string   `json:"uploaded_at"`
+	Number     string       `json:"number"`
+	Status     string       `json:"status"`
+	Accrual    *money.Money `json:"accrual,omitempty"`
+	UploadedAt string       `json:"uploaded_at"`
 }
@@ -1,7 +1,9 @@
 package dto
 
+import "gophermart/internal/app/money"
+
 type WithdrawalsResponseBody struct {
-	Number     string  `json:"order"`
-	CreatedAt  string  `json:"processed_at"`
-	Withdrawaw float64 `json:"sum"`
+	Number     string      `json:"order"`
+	CreatedAt  string      `json:"processed_at"`
+	Withdrawaw money.Money `json:"sum"`
 }
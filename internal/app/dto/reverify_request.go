@@ -0,0 +1,15 @@
+package dto
+
+import "time"
+
+type ReverifyRequest struct {
+	From    *time.Time `json:"from,omitempty"`
+	To      *time.Time `json:"to,omitempty"`
+	UserIDs []int64    `json:"user_ids,omitempty"`
+}
+
+type ReverifyResponse struct {
+	Enqueued int64 `json:"enqueued"`
+	Revived  int64 `json:"revived"`
+	Skipped  int64 `json:"skipped"`
+}
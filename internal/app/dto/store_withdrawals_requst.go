@@ -1,6 +1,27 @@
 package dto
 
+import (
+	"encoding/json"
+	"gophermart/internal/app/money"
+)
+
 type WithdrawBody struct {
-	OrderNumber string  `json:"order"`
-	Sum         float64 `json:"sum"`
+	OrderNumber string      `json:"order"`
+	Sum         money.Money `json:"sum"`
+}
+
+// UnmarshalJSON не округляет сумму списания: лишние знаки после сотых — ошибка ввода.
+func (b *WithdrawBody) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		OrderNumber string          `json:"order"`
+		Sum         json.RawMessage `json:"sum"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	b.OrderNumber = raw.OrderNumber
+	if raw.Sum == nil {
+		return nil
+	}
+	return b.Sum.UnmarshalExactJSON(raw.Sum)
 }
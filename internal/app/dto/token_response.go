@@ -0,0 +1,10 @@
+package dto
+
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type RefreshRequestBody struct {
+	RefreshToken string `json:"refresh_token"`
+}
@@ -0,0 +1,30 @@
+package entities
+
+import (
+	"database/sql"
+	"gophermart/internal/app/money"
+	"time"
+)
+
+type BalanceEntry struct {
+	CreatedAt  time.Time
+	Reason     sql.NullString
+	OrderID    sql.NullInt64
+	WithdrawID sql.NullInt64
+	Amount     money.Money
+	UserID     int64
+	ID         int64
+	TypeID     int16
+}
+
+const (
+	EntryTypeAccrual    = 1 // начисление баллов за заказ
+	EntryTypeWithdrawal = 2 // списание баллов в счёт оплаты заказа
+	EntryTypeAdjustment = 3 // корректировка баланса
+)
+
+type BalanceMismatch struct {
+	UserID int64
+	Cached money.Money
+	Ledger money.Money
+}
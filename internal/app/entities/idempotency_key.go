@@ -0,0 +1,17 @@
+package entities
+
+import (
+	"database/sql"
+	"time"
+)
+
+type IdempotencyKey struct {
+	CreatedAt    time.Time
+	CompletedAt  sql.NullTime
+	Key          string
+	Fingerprint  string
+	ContentType  string
+	ResponseBody []byte
+	UserID       int64
+	StatusCode   int
+}
@@ -5,8 +5,18 @@ import (
 )
 
 type Job struct {
-	PoolAt    *time.Time
-	CreatedAt time.Time
-	ID        int64
-	OrderID   int64
+	PoolAt            *time.Time
+	LockedUntil       *time.Time
+	NextAttemptAt     *time.Time
+	DeadAt            *time.Time
+	WorkerID          *string
+	LastError         *string
+	LastErrorCategory *string
+	TraceParent       *string
+	CreatedAt         time.Time
+	ID                int64
+	OrderID           int64
+	Attempts          int
+	Polls             int
+	Reverify          bool // задание поставлено на повторную проверку и может скорректировать начисление
 }
@@ -1,18 +1,37 @@
 package entities
 
 import (
-	"database/sql"
+	"gophermart/internal/app/money"
 	"time"
 )
 
 type Order struct {
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	Accrual   sql.NullFloat64
-	UserID    int64
-	ID        int
-	OrderID   int
-	StatusID  int16
+	// AccrualCorrectedAt и PreviousAccrual заполняются, когда повторная проверка изменила начисление.
+	AccrualCorrectedAt *time.Time
+	Accrual            money.NullMoney
+	PreviousAccrual    money.NullMoney
+	UserID             int64
+	ID                 int
+	OrderID            int
+	StatusID           int16
+}
+
+// ReverificationFilter отбирает обработанные заказы для повторной проверки начисления.
+// Период сравнивается с датой загрузки заказа, пустой список пользователей означает всех.
+type ReverificationFilter struct {
+	From    *time.Time
+	To      *time.Time
+	UserIDs []int64
+}
+
+// ReverificationResult разбирает заказы, отобранные для повторной проверки: по одним поставлены новые задания,
+// задания других возвращены из dead-letter, а заказы, чьё задание ещё в очереди, пропущены.
+type ReverificationResult struct {
+	Enqueued int64
+	Revived  int64
+	Skipped  int64
 }
 
 const (
@@ -42,8 +61,3 @@ func GetStatusName(statusID int) string {
 	}
 	return "UNKNOWN"
 }
-
-func GetStatusIDByName(statusName string) (int, bool) {
-	id, ok := StatusIds[statusName]
-	return id, ok
-}
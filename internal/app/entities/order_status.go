@@ -0,0 +1,77 @@
+package entities
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// accrualStatuses сопоставляет статусы системы начислений статусам заказа.
+// REGISTERED означает, что заказ принят, но расчёт ещё не начат: для пользователя это PROCESSING.
+var accrualStatuses = map[string]int{
+	"REGISTERED": StatusProcessing,
+	"PROCESSING": StatusProcessing,
+	"INVALID":    StatusInvalid,
+	"PROCESSED":  StatusProcessed,
+}
+
+// orderTransitions — допустимые переходы; из INVALID и PROCESSED выхода нет.
+var orderTransitions = map[int]map[int]bool{
+	StatusNew:        {StatusProcessing: true, StatusInvalid: true, StatusProcessed: true},
+	StatusProcessing: {StatusInvalid: true, StatusProcessed: true},
+	StatusInvalid:    {},
+	StatusProcessed:  {},
+}
+
+type UnknownStatusError struct {
+	Status string
+}
+
+func (e *UnknownStatusError) Error() string {
+	return fmt.Sprintf("unknown order status %q", e.Status)
+}
+
+type TransitionError struct {
+	From int
+	To   int
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("order status transition %s -> %s is not allowed", GetStatusName(e.From), GetStatusName(e.To))
+}
+
+// StatusFromAccrual переводит статус из ответа системы начислений в статус заказа.
+func StatusFromAccrual(status string) (int, error) {
+	statusID, ok := accrualStatuses[status]
+	if !ok {
+		return 0, &UnknownStatusError{Status: status}
+	}
+	return statusID, nil
+}
+
+func IsTerminalStatus(statusID int) bool {
+	return statusID == StatusInvalid || statusID == StatusProcessed
+}
+
+// CanTransition проверяет переход между статусами; повтор текущего статуса допустим.
+func CanTransition(from, to int) error {
+	allowed, ok := orderTransitions[from]
+	if !ok {
+		return &UnknownStatusError{Status: strconv.Itoa(from)}
+	}
+	if _, ok = orderTransitions[to]; !ok {
+		return &UnknownStatusError{Status: strconv.Itoa(to)}
+	}
+	if from == to || allowed[to] {
+		return nil
+	}
+	return &TransitionError{From: from, To: to}
+}
+
+// TransitionTo меняет статус заказа, если переход допустим.
+func (o *Order) TransitionTo(statusID int) error {
+	if err := CanTransition(int(o.StatusID), statusID); err != nil {
+		return err
+	}
+	o.StatusID = int16(statusID)
+	return nil
+}
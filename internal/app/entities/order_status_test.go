@@ -0,0 +1,93 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from    int
+		to      int
+		allowed bool
+	}{
+		{from: StatusNew, to: StatusNew, allowed: true},
+		{from: StatusNew, to: StatusProcessing, allowed: true},
+		{from: StatusNew, to: StatusInvalid, allowed: true},
+		{from: StatusNew, to: StatusProcessed, allowed: true},
+		{from: StatusProcessing, to: StatusNew, allowed: false},
+		{from: StatusProcessing, to: StatusProcessing, allowed: true},
+		{from: StatusProcessing, to: StatusInvalid, allowed: true},
+		{from: StatusProcessing, to: StatusProcessed, allowed: true},
+		{from: StatusInvalid, to: StatusNew, allowed: false},
+		{from: StatusInvalid, to: StatusProcessing, allowed: false},
+		{from: StatusInvalid, to: StatusInvalid, allowed: true},
+		{from: StatusInvalid, to: StatusProcessed, allowed: false},
+		{from: StatusProcessed, to: StatusNew, allowed: false},
+		{from: StatusProcessed, to: StatusProcessing, allowed: false},
+		{from: StatusProcessed, to: StatusInvalid, allowed: false},
+		{from: StatusProcessed, to: StatusProcessed, allowed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(GetStatusName(tt.from)+"->"+GetStatusName(tt.to), func(t *testing.T) {
+			err := CanTransition(tt.from, tt.to)
+			if tt.allowed {
+				assert.NoError(t, err)
+				return
+			}
+			var transitionErr *TransitionError
+			require.ErrorAs(t, err, &transitionErr)
+			assert.Equal(t, tt.from, transitionErr.From)
+			assert.Equal(t, tt.to, transitionErr.To)
+		})
+	}
+}
+
+func TestCanTransition_UnknownStatus(t *testing.T) {
+	var unknownErr *UnknownStatusError
+	assert.ErrorAs(t, CanTransition(0, StatusProcessed), &unknownErr)
+	assert.ErrorAs(t, CanTransition(StatusNew, 42), &unknownErr)
+}
+
+func TestStatusFromAccrual(t *testing.T) {
+	tests := []struct {
+		status   string
+		expected int
+		wantErr  bool
+	}{
+		{status: "REGISTERED", expected: StatusProcessing},
+		{status: "PROCESSING", expected: StatusProcessing},
+		{status: "INVALID", expected: StatusInvalid},
+		{status: "PROCESSED", expected: StatusProcessed},
+		{status: "NEW", wantErr: true},
+		{status: "processed", wantErr: true},
+		{status: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			statusID, err := StatusFromAccrual(tt.status)
+			if tt.wantErr {
+				var unknownErr *UnknownStatusError
+				require.ErrorAs(t, err, &unknownErr)
+				assert.Equal(t, tt.status, unknownErr.Status)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, statusID)
+		})
+	}
+}
+
+func TestOrderTransitionTo(t *testing.T) {
+	order := Order{StatusID: StatusNew}
+	require.NoError(t, order.TransitionTo(StatusProcessing))
+	require.NoError(t, order.TransitionTo(StatusProcessed))
+	assert.Equal(t, int16(StatusProcessed), order.StatusID)
+
+	assert.Error(t, order.TransitionTo(StatusInvalid))
+	assert.Equal(t, int16(StatusProcessed), order.StatusID)
+}
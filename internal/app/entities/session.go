@@ -0,0 +1,24 @@
+package entities
+
+import "time"
+
+// Session — семейство токенов обновления, выданных при одном входе пользователя.
+// Отзыв сессии отзывает все её токены, а токены доступа перестают приниматься.
+type Session struct {
+	CreatedAt time.Time
+	RevokedAt *time.Time
+	ID        int64
+	UserID    int
+}
+
+// RefreshToken хранится только в виде хэша. UsedAt заполняется при обмене на новую пару токенов.
+type RefreshToken struct {
+	CreatedAt        time.Time
+	ExpiresAt        time.Time
+	UsedAt           *time.Time
+	SessionRevokedAt *time.Time
+	Hash             string
+	ID               int64
+	SessionID        int64
+	UserID           int
+}
@@ -1,10 +1,10 @@
 package entities
 
-import "database/sql"
+import "gophermart/internal/app/money"
 
 type User struct {
 	Login    string
 	Password string
-	Balance  sql.NullFloat64
+	Balance  money.Money
 	ID       int
 }
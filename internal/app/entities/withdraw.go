@@ -1,12 +1,13 @@
 package entities
 
 import (
+	"gophermart/internal/app/money"
 	"time"
 )
 
 type Withdraw struct {
 	CreatedAt time.Time
-	Withdraw  float64
+	Withdraw  money.Money
 	UserID    int64
 	ID        int
 	OrderID   int
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"gophermart/internal/app/apperrors"
+	"gophermart/internal/app/entities"
+	"gophermart/internal/app/services"
+	"gophermart/internal/app/services/accrual"
+	"gophermart/internal/app/utils"
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+type AccrualCallbackHandler struct {
+	CallbackService services.AccrualCallbackService
+	Logger          *zap.SugaredLogger
+}
+
+func NewAccrualCallbackHandler(
+	callbackService services.AccrualCallbackService,
+	logger *zap.SugaredLogger,
+) *AccrualCallbackHandler {
+	handlerLogger := logger.With("component:NewAccrualCallbackHandler", "AccrualCallbackHandler")
+	return &AccrualCallbackHandler{
+		CallbackService: callbackService,
+		Logger:          handlerLogger,
+	}
+}
+
+// ApplyCallback принимает уведомление системы начислений в том же формате, что и ответ GET /api/orders/{number}.
+// Подпись проверяется middleware AccrualCallbackSignature.
+func (h *AccrualCallbackHandler) ApplyCallback() http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		var orderResponse accrual.OrderResponse
+		if err := json.NewDecoder(request.Body).Decode(&orderResponse); err != nil {
+			response.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		number, err := strconv.ParseInt(orderResponse.Order, 10, 64)
+		if err != nil {
+			response.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		err = h.CallbackService.ApplyCallback(request.Context(), number, &orderResponse)
+		if err != nil {
+			var unknownErr *entities.UnknownStatusError
+			var transitionErr *entities.TransitionError
+			switch {
+			case errors.As(err, &unknownErr):
+				response.WriteHeader(http.StatusBadRequest)
+			case errors.Is(err, apperrors.ErrOrderNotFound):
+				response.WriteHeader(http.StatusNotFound)
+			case errors.As(err, &transitionErr):
+				// заказ уже в другом конечном статусе, уведомление устарело
+				response.WriteHeader(http.StatusConflict)
+			default:
+				utils.ContextLogger(request.Context(), h.Logger).Infoln("error ApplyCallback", err)
+				response.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+		response.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"fmt"
+	"gophermart/internal/app/apperrors"
+	"gophermart/internal/app/entities"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestAccrualCallbackHandler_ApplyCallback(t *testing.T) {
+	tests := []struct {
+		serviceErr    error
+		name          string
+		body          string
+		expectedCode  int
+		expectApplied bool
+	}{
+		{
+			name:          "applies notification",
+			body:          `{"order":"12345678903","status":"PROCESSED","accrual":500}`,
+			expectedCode:  http.StatusOK,
+			expectApplied: true,
+		},
+		{
+			name:         "malformed body",
+			body:         `{"order":`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "order is not a number",
+			body:         `{"order":"abc","status":"PROCESSED"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:          "unknown status",
+			body:          `{"order":"12345678903","status":"CANCELLED"}`,
+			serviceErr:    &entities.UnknownStatusError{Status: "CANCELLED"},
+			expectedCode:  http.StatusBadRequest,
+			expectApplied: true,
+		},
+		{
+			name:          "unknown order",
+			body:          `{"order":"12345678903","status":"PROCESSED"}`,
+			serviceErr:    fmt.Errorf("failed to GetByOrderNumber: %w", apperrors.ErrOrderNotFound),
+			expectedCode:  http.StatusNotFound,
+			expectApplied: true,
+		},
+		{
+			name:          "order already in another final status",
+			body:          `{"order":"12345678903","status":"PROCESSED"}`,
+			serviceErr:    &entities.TransitionError{From: entities.StatusInvalid, To: entities.StatusProcessed},
+			expectedCode:  http.StatusConflict,
+			expectApplied: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &stubAccrualService{callbackErr: tt.serviceErr}
+			handler := NewAccrualCallbackHandler(service, zap.NewNop().Sugar())
+
+			request := httptest.NewRequest(http.MethodPost, "/internal/accrual/callback", strings.NewReader(tt.body))
+			recorder := httptest.NewRecorder()
+			handler.ApplyCallback().ServeHTTP(recorder, request)
+
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			if !tt.expectApplied {
+				assert.Empty(t, service.callbacks)
+				return
+			}
+			require.Len(t, service.callbacks, 1)
+			assert.Equal(t, "12345678903", service.callbacks[0].Order)
+		})
+	}
+}
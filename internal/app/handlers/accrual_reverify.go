@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"gophermart/internal/app/dto"
+	"gophermart/internal/app/entities"
+	"gophermart/internal/app/services"
+	"gophermart/internal/app/utils"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+type AccrualReverifyHandler struct {
+	CallbackService services.AccrualCallbackService
+	Logger          *zap.SugaredLogger
+}
+
+func NewAccrualReverifyHandler(
+	callbackService services.AccrualCallbackService,
+	logger *zap.SugaredLogger,
+) *AccrualReverifyHandler {
+	handlerLogger := logger.With("component:NewAccrualReverifyHandler", "AccrualReverifyHandler")
+	return &AccrualReverifyHandler{
+		CallbackService: callbackService,
+		Logger:          handlerLogger,
+	}
+}
+
+// Reverify ставит в очередь повторную проверку начислений за период загрузки заказов или по пользователям.
+// Пустой фильтр отклоняется, чтобы случайно не перепроверить все заказы.
+func (h *AccrualReverifyHandler) Reverify() http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		var req dto.ReverifyRequest
+		if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+			response.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		hasPeriod := req.From != nil && req.To != nil
+		if (req.From == nil) != (req.To == nil) || (hasPeriod && !req.From.Before(*req.To)) {
+			response.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if !hasPeriod && len(req.UserIDs) == 0 {
+			response.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		result, err := h.CallbackService.EnqueueReverification(request.Context(), entities.ReverificationFilter{
+			From:    req.From,
+			To:      req.To,
+			UserIDs: req.UserIDs,
+		})
+		if err != nil {
+			utils.ContextLogger(request.Context(), h.Logger).Infoln("error EnqueueReverification", err)
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		utils.ContextLogger(request.Context(), h.Logger).Infow("orders enqueued for accrual reverification",
+			"enqueued", result.Enqueued, "revived", result.Revived, "skipped", result.Skipped,
+			"from", req.From, "to", req.To, "users", req.UserIDs)
+
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(http.StatusAccepted)
+		body := dto.ReverifyResponse{Enqueued: result.Enqueued, Revived: result.Revived, Skipped: result.Skipped}
+		if err = json.NewEncoder(response).Encode(body); err != nil {
+			utils.ContextLogger(request.Context(), h.Logger).Infoln("error Encode reverify response", err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestAccrualReverifyHandler_Reverify(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		expectedBody string
+		expectedCode int
+	}{
+		{
+			name:         "period",
+			body:         `{"from":"2024-01-01T00:00:00Z","to":"2024-02-01T00:00:00Z"}`,
+			expectedCode: http.StatusAccepted,
+			expectedBody: `{"enqueued":0,"revived":0,"skipped":1}`,
+		},
+		{
+			name:         "users",
+			body:         `{"user_ids":[7,8]}`,
+			expectedCode: http.StatusAccepted,
+			expectedBody: `{"enqueued":2,"revived":0,"skipped":1}`,
+		},
+		{
+			name:         "empty filter",
+			body:         `{}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "open period",
+			body:         `{"from":"2024-01-01T00:00:00Z"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "reversed period",
+			body:         `{"from":"2024-02-01T00:00:00Z","to":"2024-01-01T00:00:00Z"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "malformed body",
+			body:         `{"user_ids":`,
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &stubAccrualService{}
+			handler := NewAccrualReverifyHandler(service, zap.NewNop().Sugar())
+
+			request := httptest.NewRequest(http.MethodPost, "/internal/accrual/reverify", strings.NewReader(tt.body))
+			recorder := httptest.NewRecorder()
+			handler.Reverify().ServeHTTP(recorder, request)
+
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			if tt.expectedCode != http.StatusAccepted {
+				assert.Empty(t, service.reverified)
+				return
+			}
+			require.Len(t, service.reverified, 1)
+			assert.JSONEq(t, tt.expectedBody, recorder.Body.String())
+		})
+	}
+}
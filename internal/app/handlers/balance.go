@@ -5,6 +5,7 @@ import (
 	"errors"
 	"gophermart/internal/app/apperrors"
 	"gophermart/internal/app/dto"
+	"gophermart/internal/app/money"
 	"gophermart/internal/app/services"
 	"gophermart/internal/app/utils"
 	"net/http"
@@ -41,7 +42,7 @@ func (b *BalanceHandler) GetUserBalance() http.HandlerFunc {
 		response.Header().Set("Content-Type", "application/json")
 		err = json.NewEncoder(response).Encode(balance)
 		if err != nil {
-			b.Logger.Infoln("error Encode balance", err)
+			utils.ContextLogger(request.Context(), b.Logger).Infoln("error Encode balance", err)
 			response.WriteHeader(http.StatusInternalServerError)
 			return
 		}
@@ -59,6 +60,11 @@ func (b *BalanceHandler) StoreBalanceWithdraw() http.HandlerFunc {
 		}
 		var req dto.WithdrawBody
 		if err = json.NewDecoder(request.Body).Decode(&req); err != nil {
+			if errors.Is(err, money.ErrTooPrecise) {
+				// сумма точнее сотых не округляется молча;
+				response.WriteHeader(http.StatusUnprocessableEntity)
+				return
+			}
 			response.WriteHeader(http.StatusBadRequest)
 			return
 		}
@@ -66,12 +72,19 @@ func (b *BalanceHandler) StoreBalanceWithdraw() http.HandlerFunc {
 			if errors.Is(err, apperrors.ErrDuplicateOrderID) {
 				// номер заказа уже был загружен этим пользователем;
 				response.WriteHeader(http.StatusOK)
+				return
+			}
+			if errors.Is(err, apperrors.ErrInvalidWithdrawSum) {
+				// сумма списания не положительна;
+				response.WriteHeader(http.StatusUnprocessableEntity)
+				return
 			}
 			if errors.Is(err, apperrors.ErrBalanceNotEnought) {
 				// на счету недостаточно средств;
 				response.WriteHeader(http.StatusPaymentRequired)
+				return
 			}
-			b.Logger.Infoln("error save withdraw", err)
+			utils.ContextLogger(request.Context(), b.Logger).Infoln("error save withdraw", err)
 			response.WriteHeader(http.StatusInternalServerError)
 			return
 		}
@@ -89,14 +102,14 @@ func (b *BalanceHandler) GetWithdrawals() http.HandlerFunc {
 		}
 		withdrawals, err := b.BalanceService.GetWithdrawals(ctx, userID)
 		if err != nil {
-			b.Logger.Infoln("error GetWithdrawals", err)
+			utils.ContextLogger(request.Context(), b.Logger).Infoln("error GetWithdrawals", err)
 			response.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 		response.Header().Set("Content-Type", "application/json")
 		err = json.NewEncoder(response).Encode(withdrawals)
 		if err != nil {
-			b.Logger.Infoln("error Encode withdrawals", err)
+			utils.ContextLogger(request.Context(), b.Logger).Infoln("error Encode withdrawals", err)
 			response.WriteHeader(http.StatusInternalServerError)
 			return
 		}
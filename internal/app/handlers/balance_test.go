@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"gophermart/internal/app/dto"
+	"gophermart/internal/app/money"
+	"gophermart/internal/app/services"
+	"gophermart/internal/app/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// stubBalanceService запоминает списания; остальные методы в тестах не вызываются.
+type stubBalanceService struct {
+	services.BalanceService
+	withdrawals []dto.WithdrawBody
+}
+
+func (s *stubBalanceService) Withdraw(_ context.Context, _ int, req dto.WithdrawBody) error {
+	s.withdrawals = append(s.withdrawals, req)
+	return nil
+}
+
+func TestBalanceHandler_StoreBalanceWithdraw(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		expectedSum  money.Money
+		expectedCode int
+	}{
+		{
+			name:         "whole points",
+			body:         `{"order":"2377225624","sum":751}`,
+			expectedCode: http.StatusOK,
+			expectedSum:  money.FromMinorUnits(75100),
+		},
+		{
+			name:         "hundredths",
+			body:         `{"order":"2377225624","sum":751.25}`,
+			expectedCode: http.StatusOK,
+			expectedSum:  money.FromMinorUnits(75125),
+		},
+		{
+			name:         "more than two decimals",
+			body:         `{"order":"2377225624","sum":751.255}`,
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "more than two decimals in exponent form",
+			body:         `{"order":"2377225624","sum":1e-3}`,
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "malformed sum",
+			body:         `{"order":"2377225624","sum":"abc"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &stubBalanceService{}
+			handler := NewBalanceHandler(service, zap.NewNop().Sugar())
+
+			request := httptest.NewRequest(http.MethodPost, "/api/user/balance/withdraw", strings.NewReader(tt.body))
+			request = request.WithContext(utils.SetUserID(request.Context(), 7))
+			recorder := httptest.NewRecorder()
+			handler.StoreBalanceWithdraw().ServeHTTP(recorder, request)
+
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			if tt.expectedCode != http.StatusOK {
+				assert.Empty(t, service.withdrawals)
+				return
+			}
+			require.Len(t, service.withdrawals, 1)
+			assert.Equal(t, "2377225624", service.withdrawals[0].OrderNumber)
+			assert.Equal(t, tt.expectedSum, service.withdrawals[0].Sum)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"encoding/json"
+	"gophermart/internal/app/utils"
+	"gophermart/internal/config"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+type ConfigReloader interface {
+	Reload() (config.ReloadResult, error)
+}
+
+type ConfigReloadHandler struct {
+	Reloader ConfigReloader
+	Logger   *zap.SugaredLogger
+}
+
+func NewConfigReloadHandler(reloader ConfigReloader, logger *zap.SugaredLogger) *ConfigReloadHandler {
+	handlerLogger := logger.With("component:NewConfigReloadHandler", "ConfigReloadHandler")
+	return &ConfigReloadHandler{
+		Reloader: reloader,
+		Logger:   handlerLogger,
+	}
+}
+
+// Reload перечитывает конфигурацию так же, как по SIGHUP. Ошибки конфигурации возвращаются оператору текстом,
+// прежние значения при этом остаются в силе.
+func (h *ConfigReloadHandler) Reload() http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		requestLogger := utils.ContextLogger(request.Context(), h.Logger)
+		result, err := h.Reloader.Reload()
+		if err != nil {
+			requestLogger.Infoln("error Reload config", err)
+			http.Error(response, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		requestLogger.Infow("config reloaded", "applied", result.Applied, "restart_required", result.RestartRequired)
+
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(http.StatusOK)
+		if err = json.NewEncoder(response).Encode(result); err != nil {
+			requestLogger.Infoln("error Encode reload response", err)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"gophermart/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type stubConfigReloader struct {
+	err    error
+	result config.ReloadResult
+}
+
+func (r *stubConfigReloader) Reload() (config.ReloadResult, error) {
+	return r.result, r.err
+}
+
+func TestConfigReloadHandler_Reload(t *testing.T) {
+	tests := []struct {
+		reloader     *stubConfigReloader
+		name         string
+		expectedBody string
+		expectedCode int
+	}{
+		{
+			name: "applied",
+			reloader: &stubConfigReloader{result: config.ReloadResult{
+				Applied:         []string{"rate_limit"},
+				RestartRequired: []string{"run_address"},
+			}},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"applied":["rate_limit"],"restart_required":["run_address"]}`,
+		},
+		{
+			name:         "invalid config",
+			reloader:     &stubConfigReloader{err: errors.New("RateLimit (0) должен быть положительным")},
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedBody: "RateLimit (0) должен быть положительным",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewConfigReloadHandler(tt.reloader, zap.NewNop().Sugar())
+
+			request := httptest.NewRequest(http.MethodPost, "/internal/config/reload", http.NoBody)
+			recorder := httptest.NewRecorder()
+			handler.Reload()(recorder, request)
+
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			if tt.expectedCode == http.StatusOK {
+				assert.JSONEq(t, tt.expectedBody, recorder.Body.String())
+			} else {
+				assert.Contains(t, recorder.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+}
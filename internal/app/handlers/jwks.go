@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"encoding/json"
+	"gophermart/internal/app/services"
+	"gophermart/internal/app/utils"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+type JwksHandler struct {
+	JwtService services.JwtService
+	Logger     *zap.SugaredLogger
+}
+
+func NewJwksHandler(
+	jwtService services.JwtService,
+	logger *zap.SugaredLogger,
+) *JwksHandler {
+	handlerLogger := logger.With("component:NewJwksHandler", "JwksHandler")
+	return &JwksHandler{
+		JwtService: jwtService,
+		Logger:     handlerLogger,
+	}
+}
+
+// GetJwks отдаёт открытые ключи, которыми другие сервисы проверяют токены гофермарта.
+func (h *JwksHandler) GetJwks() http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		response.Header().Set("Content-Type", "application/json")
+		// ключи меняются только при перезапуске с новой конфигурацией
+		response.Header().Set("Cache-Control", "public, max-age=300")
+		if err := json.NewEncoder(response).Encode(h.JwtService.JWKS()); err != nil {
+			utils.ContextLogger(request.Context(), h.Logger).Infoln("error Encode jwks", err)
+			response.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+}
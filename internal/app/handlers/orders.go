@@ -51,7 +51,7 @@ func (o *OrderHandler) GetUserOrders() http.HandlerFunc {
 		response.Header().Set("Content-Type", "application/json")
 		err = json.NewEncoder(response).Encode(orders)
 		if err != nil {
-			o.Logger.Infoln("error Encode orders", err)
+			utils.ContextLogger(request.Context(), o.Logger).Infoln("error Encode orders", err)
 			response.WriteHeader(http.StatusInternalServerError)
 			return
 		}
@@ -75,7 +75,7 @@ func (o *OrderHandler) StoreOrders() http.HandlerFunc {
 		defer func(Body io.ReadCloser) {
 			err = Body.Close()
 			if err != nil {
-				o.Logger.Infoln("error close body", err)
+				utils.ContextLogger(request.Context(), o.Logger).Infoln("error close body", err)
 				response.WriteHeader(http.StatusInternalServerError)
 				return
 			}
@@ -107,7 +107,7 @@ func (o *OrderHandler) StoreOrders() http.HandlerFunc {
 				response.WriteHeader(http.StatusOK)
 				return
 			}
-			o.Logger.Infoln("error SaveOrder", err)
+			utils.ContextLogger(request.Context(), o.Logger).Infoln("error SaveOrder", err)
 			response.WriteHeader(http.StatusInternalServerError)
 			return
 		}
@@ -6,19 +6,31 @@ import (
 	"gophermart/internal/app/entities"
 	"gophermart/internal/app/services"
 	"gophermart/internal/app/services/accrual"
+	"gophermart/internal/app/utils"
 	"gophermart/internal/config"
+	"gophermart/internal/tracing"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+// tracer открывает корневые span агента: задания обрабатываются вне HTTP-запросов.
+var tracer = otel.Tracer("gophermart/internal/app/handlers")
+
 type SendOrderHandler struct {
 	SendOrderService services.AccrualService
 	Cfg              *config.Config
 	sendQueue        chan *entities.Job
+	inFlight         map[int64]struct{}
 	Logger           *zap.SugaredLogger
 	mu               *sync.RWMutex
+	reconfigured     chan struct{}
+	pending          atomic.Pointer[config.Config]
 }
 
 func NewSendOrderHandler(
@@ -33,69 +45,284 @@ func NewSendOrderHandler(
 		Cfg:              cfg,
 		Logger:           handlerLogger,
 		sendQueue:        sendQueue,
+		inFlight:         make(map[int64]struct{}),
 		mu:               &sync.RWMutex{},
+		reconfigured:     make(chan struct{}, 1),
 	}
 }
 
-func (h *SendOrderHandler) SendUserOrders() error {
-	ctx := context.Background()
-	timer := time.NewTimer(h.Cfg.PollInterval)
-	defer timer.Stop()
+// Reconfigure передаёт агенту новые RateLimit, PollInterval и AgentOrderLimit.
+// Они применяются в цикле опроса: задания, которые уже обрабатываются, не прерываются.
+func (h *SendOrderHandler) Reconfigure(cfg *config.Config) {
+	h.pending.Store(cfg)
+	select {
+	case h.reconfigured <- struct{}{}:
+	default:
+	}
+}
+
+// SendUserOrders опрашивает очередь заданий каждые PollInterval, пока не будет отменён ctx.
+// Задание, начатое воркером до остановки, получает ShutdownTimeout на завершение транзакции.
+func (h *SendOrderHandler) SendUserOrders(ctx context.Context) error {
+	jobCtx, cancelJobs := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelJobs()
 
 	var wg sync.WaitGroup
-	for range make([]struct{}, h.Cfg.RateLimit) {
+	pool := &workerPool{start: func(stop <-chan struct{}) {
 		wg.Add(1)
-		go h.worker(ctx, &wg, timer)
+		go h.worker(ctx, jobCtx, stop, &wg)
+	}}
+	pool.resize(h.Cfg.RateLimit)
+
+	loop := &pollLoop{
+		pool:         pool,
+		ticker:       time.NewTicker(h.Cfg.PollInterval),
+		pollInterval: h.Cfg.PollInterval,
+		orderLimit:   h.Cfg.AgentOrderLimit,
+	}
+	defer loop.ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			h.Logger.Info("Shutting down gracefully...")
+			close(h.sendQueue)
+			h.waitWorkers(&wg, cancelJobs)
+			return nil
+		case <-h.reconfigured:
+			h.applyConfig(loop)
+		case <-loop.ticker.C:
+			h.dispatchPendingJobs(ctx, loop)
+		}
+	}
+}
+
+// pollLoop — состояние цикла опроса, меняется только из него.
+type pollLoop struct {
+	pool         *workerPool
+	ticker       *time.Ticker
+	pollInterval time.Duration
+	orderLimit   int
+}
+
+func (h *SendOrderHandler) applyConfig(loop *pollLoop) {
+	cfg := h.pending.Load()
+	loop.pool.resize(cfg.RateLimit)
+	if cfg.PollInterval != loop.pollInterval {
+		// новый интервал отсчитывается от следующего тика
+		loop.pollInterval = cfg.PollInterval
+		loop.ticker.Reset(loop.pollInterval)
 	}
+	loop.orderLimit = cfg.AgentOrderLimit
+	h.Logger.Infow("accrual agent reconfigured",
+		"workers", loop.pool.size(), "poll_interval", loop.pollInterval, "order_limit", loop.orderLimit)
+}
 
+// workerPool меняет число воркеров на ходу. Остановленный воркер дорабатывает текущее задание.
+type workerPool struct {
+	start func(stop <-chan struct{})
+	stops []chan struct{}
+}
+
+func (p *workerPool) resize(size int) {
+	for len(p.stops) < size {
+		stop := make(chan struct{})
+		p.stops = append(p.stops, stop)
+		p.start(stop)
+	}
+	for len(p.stops) > size {
+		last := len(p.stops) - 1
+		close(p.stops[last])
+		p.stops = p.stops[:last]
+	}
+}
+
+func (p *workerPool) size() int {
+	return len(p.stops)
+}
+
+func (h *SendOrderHandler) waitWorkers(wg *sync.WaitGroup, cancelJobs context.CancelFunc) {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(h.Cfg.ShutdownTimeout)
+	defer timer.Stop()
 	select {
-	case <-ctx.Done():
-		h.Logger.Info("Shutting down gracefully...")
-		return nil
+	case <-done:
 	case <-timer.C:
-		jobs, err := h.SendOrderService.GetPendingJobs(ctx, h.Cfg.AgentOrderLimit)
-		if err != nil {
-			h.Logger.Errorf("Failed to get jobs: %v", err)
-			timer.Reset(h.Cfg.PollInterval)
-			break
-		}
-		if len(jobs) == 0 {
-			timer.Reset(h.Cfg.PollInterval)
-			break
+		h.Logger.Infoln("Shutdown timeout exceeded, cancelling in-flight jobs")
+		cancelJobs()
+		<-done
+	}
+}
+
+func (h *SendOrderHandler) dispatchPendingJobs(ctx context.Context, loop *pollLoop) {
+	jobs, err := h.SendOrderService.ClaimJobs(ctx, loop.orderLimit)
+	if err != nil {
+		h.Logger.Errorf("Failed to get jobs: %v", err)
+		return
+	}
+	acquired := make([]*entities.Job, 0, len(jobs))
+	for i := range jobs {
+		// задание ещё обрабатывается воркером с прошлого тика, его аренду не трогаем
+		if h.acquire(jobs[i].ID) {
+			acquired = append(acquired, &jobs[i])
 		}
-		for _, job := range jobs {
-			select {
-			case h.sendQueue <- &job:
-			case <-ctx.Done():
-				return nil
+	}
+	for i, job := range acquired {
+		if !h.enqueue(ctx, loop, job) {
+			// аренда не нужна до её истечения, задания сразу доступны другим экземплярам
+			for _, undispatched := range acquired[i:] {
+				h.release(undispatched.ID)
+				h.releaseLease(undispatched)
 			}
+			return
 		}
 	}
+}
 
-	wg.Wait()
-	close(h.sendQueue)
-
-	return nil
+// enqueue ждёт свободного воркера и тем временем применяет новую конфигурацию:
+// когда все воркеры заняты, оператору и нужно изменить их число.
+func (h *SendOrderHandler) enqueue(ctx context.Context, loop *pollLoop, job *entities.Job) bool {
+	for {
+		select {
+		case h.sendQueue <- job:
+			return true
+		case <-h.reconfigured:
+			h.applyConfig(loop)
+		case <-ctx.Done():
+			return false
+		}
+	}
 }
 
-func (h *SendOrderHandler) worker(ctx context.Context, wg *sync.WaitGroup, timer *time.Timer) {
+func (h *SendOrderHandler) worker(
+	ctx context.Context,
+	jobCtx context.Context,
+	stop <-chan struct{},
+	wg *sync.WaitGroup,
+) {
 	defer wg.Done()
 
-	for job := range h.sendQueue {
-		h.mu.Lock()
-		err := h.SendOrderService.SendOrder(ctx, job)
-		h.mu.Unlock()
+	for {
+		select {
+		case <-stop:
+			return
+		case job, ok := <-h.sendQueue:
+			if !ok {
+				return
+			}
+			h.process(ctx, jobCtx, job)
+		}
+	}
+}
 
+func (h *SendOrderHandler) process(ctx context.Context, jobCtx context.Context, job *entities.Job) {
+	if ctx.Err() != nil {
+		h.release(job.ID)
+		h.releaseLease(job)
+		return
+	}
+	// задание могло ждать свободного воркера дольше аренды: продлеваем её и проверяем, что оно всё ещё наше
+	leased, err := h.SendOrderService.ExtendLease(jobCtx, job)
+	if err != nil || !leased {
+		h.release(job.ID)
 		if err != nil {
-			var tooManyReqErr *accrual.TooManyRequestsWithRetryError
-			if errors.As(err, &tooManyReqErr) {
-				h.Logger.Infof("Слишком много запросов, пауза %d секунд\n", tooManyReqErr.RetryAfter)
-				after := time.Duration(tooManyReqErr.RetryAfter) * time.Second
-				timer.Reset(after)
-				<-timer.C
-			} else {
-				h.Logger.Infof("Failed job with order id %d: %v\n", job.OrderID, err)
+			utils.ContextLogger(jobCtx, h.Logger).Infof("Failed to extend lease of job %d: %v\n", job.ID, err)
+		} else {
+			utils.ContextLogger(jobCtx, h.Logger).Infof("Job %d is leased by another worker, skipping\n", job.ID)
+		}
+		return
+	}
+	// запрос загрузки заказа давно завершён: у задания своя трасса, а связь с запросом хранит ссылка
+	jobCtx, span := tracer.Start(jobCtx, "SendOrderHandler.process",
+		trace.WithNewRoot(),
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithLinks(tracing.Links(job.TraceParent)...),
+		trace.WithAttributes(attribute.Int64("order.id", job.OrderID)),
+	)
+	sendCtx, cancelSend := context.WithCancel(jobCtx)
+	stopRenewal := h.renewLease(sendCtx, job, cancelSend)
+	err = h.SendOrderService.SendOrder(sendCtx, job)
+	stopRenewal()
+	cancelSend()
+	tracing.End(span, err)
+	h.release(job.ID)
+
+	if err != nil {
+		var tooManyReqErr *accrual.TooManyRequestsWithRetryError
+		if errors.As(err, &tooManyReqErr) {
+			// пауза общая для всех воркеров и выдерживается в клиенте системы начислений
+			utils.ContextLogger(jobCtx, h.Logger).Infof("Слишком много запросов, пауза %d секунд\n", tooManyReqErr.RetryAfter)
+		} else {
+			utils.ContextLogger(jobCtx, h.Logger).Infof("Failed job with order id %d: %v\n", job.OrderID, err)
+		}
+	}
+}
+
+// renewLease продлевает аренду каждые пол-срока, пока задание обрабатывается:
+// запрос может ждать паузы после ответа 429 дольше AgentLeaseTimeout.
+// Если аренду перехватил другой экземпляр, отправка прерывается через cancel.
+func (h *SendOrderHandler) renewLease(
+	ctx context.Context,
+	job *entities.Job,
+	cancel context.CancelFunc,
+) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(h.Cfg.AgentLeaseTimeout / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				leased, err := h.SendOrderService.ExtendLease(ctx, job)
+				if err != nil {
+					utils.ContextLogger(ctx, h.Logger).Infof("Failed to extend lease of job %d: %v\n", job.ID, err)
+					continue
+				}
+				if !leased {
+					utils.ContextLogger(ctx, h.Logger).Infof("Job %d is leased by another worker, cancelling\n", job.ID)
+					cancel()
+					return
+				}
 			}
 		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
 	}
 }
+
+func (h *SendOrderHandler) releaseLease(job *entities.Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.Cfg.ShutdownTimeout)
+	defer cancel()
+	if err := h.SendOrderService.ReleaseJob(ctx, job); err != nil {
+		h.Logger.Infof("Failed to release job %d: %v\n", job.ID, err)
+	}
+}
+
+func (h *SendOrderHandler) acquire(jobID int64) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.inFlight[jobID]; ok {
+		return false
+	}
+	h.inFlight[jobID] = struct{}{}
+	return true
+}
+
+func (h *SendOrderHandler) release(jobID int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.inFlight, jobID)
+}
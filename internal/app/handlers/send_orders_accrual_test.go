@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"context"
+	"gophermart/internal/app/entities"
+	"gophermart/internal/app/money"
+	"gophermart/internal/app/repositories/mocks"
+	"gophermart/internal/app/services"
+	"gophermart/internal/app/services/accrual/fake"
+	"gophermart/internal/config"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type noopTx struct {
+	pgx.Tx
+}
+
+func (noopTx) Commit(context.Context) error   { return nil }
+func (noopTx) Rollback(context.Context) error { return nil }
+
+type noopTxBeginner struct{}
+
+func (noopTxBeginner) Begin(context.Context) (pgx.Tx, error) {
+	return noopTx{}, nil
+}
+
+// memoryStore — таблицы jobs, orders и баланс пользователей в памяти; транзакции не изолируются.
+type memoryStore struct {
+	jobs     map[int64]*entities.Job
+	orders   map[int64]*entities.Order
+	leased   map[int64]bool
+	balances map[int64]money.Money
+	mu       sync.Mutex
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		jobs:     make(map[int64]*entities.Job),
+		orders:   make(map[int64]*entities.Order),
+		leased:   make(map[int64]bool),
+		balances: make(map[int64]money.Money),
+	}
+}
+
+func (s *memoryStore) addOrder(id int64, number int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orders[id] = &entities.Order{ID: int(id), OrderID: number, UserID: 1, StatusID: entities.StatusNew}
+	s.jobs[id] = &entities.Job{ID: id, OrderID: id}
+}
+
+func (s *memoryStore) order(id int64) entities.Order {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.orders[id]
+}
+
+func (s *memoryStore) job(id int64) *entities.Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job, ok := s.jobs[id]; ok {
+		copied := *job
+		return &copied
+	}
+	return nil
+}
+
+func (s *memoryStore) balance(userID int64) money.Money {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.balances[userID]
+}
+
+func (s *memoryStore) claim(_ context.Context, _ string, limit int, _ time.Duration) ([]entities.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var claimed []entities.Job
+	for id, job := range s.jobs {
+		if len(claimed) == limit {
+			break
+		}
+		if s.leased[id] || job.DeadAt != nil || (job.NextAttemptAt != nil && job.NextAttemptAt.After(time.Now())) {
+			continue
+		}
+		s.leased[id] = true
+		claimed = append(claimed, *job)
+	}
+	return claimed, nil
+}
+
+func (s *memoryStore) saveJob(_ context.Context, _ pgx.Tx, job *entities.Job, _ string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *job
+	s.jobs[job.ID] = &copied
+	delete(s.leased, job.ID)
+	return nil
+}
+
+func (s *memoryStore) markDead(ctx context.Context, tx pgx.Tx, job *entities.Job, workerID string) error {
+	now := time.Now()
+	dead := *job
+	dead.DeadAt = &now
+	return s.saveJob(ctx, tx, &dead, workerID)
+}
+
+func (s *memoryStore) deleteJob(_ context.Context, _ pgx.Tx, jobID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.jobs, jobID)
+	delete(s.leased, jobID)
+	return nil
+}
+
+func (s *memoryStore) getOrder(_ context.Context, _ pgx.Tx, orderID int64) (*entities.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	order := *s.orders[orderID]
+	return &order, nil
+}
+
+func (s *memoryStore) updateOrder(_ context.Context, _ pgx.Tx, order *entities.Order) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	updated := *order
+	s.orders[int64(order.ID)] = &updated
+	return nil
+}
+
+func (s *memoryStore) addBalance(_ context.Context, _ pgx.Tx, amount money.Money, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.balances[userID] += amount
+	return nil
+}
+
+func newAccrualHandlerUnderTest(t *testing.T, store *memoryStore, client *fake.Client) *SendOrderHandler {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+
+	jobRepo := mocks.NewMockJobRepositoryInterface(ctrl)
+	jobRepo.EXPECT().ClaimJobs(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(store.claim).AnyTimes()
+	jobRepo.EXPECT().ScheduleRetry(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(store.saveJob).AnyTimes()
+	jobRepo.EXPECT().ScheduleNextPoll(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(store.saveJob).AnyTimes()
+	jobRepo.EXPECT().MarkJobDead(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(store.markDead).AnyTimes()
+	jobRepo.EXPECT().DeleteJobByID(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(store.deleteJob).AnyTimes()
+	jobRepo.EXPECT().ReleaseJob(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	jobRepo.EXPECT().ExtendLease(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil).AnyTimes()
+
+	orderRepo := mocks.NewMockOrderRepositoryInterface(ctrl)
+	orderRepo.EXPECT().GetByID(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(store.getOrder).AnyTimes()
+	orderRepo.EXPECT().LockByID(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(store.getOrder).AnyTimes()
+	orderRepo.EXPECT().UpdateOrder(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(store.updateOrder).AnyTimes()
+
+	userRepo := mocks.NewMockUserRepositoryInterface(ctrl)
+	userRepo.EXPECT().LockBalanceByUserID(gomock.Any(), gomock.Any(), gomock.Any()).Return(money.Money(0), nil).AnyTimes()
+	userRepo.EXPECT().AddBalanceByUserID(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(store.addBalance).AnyTimes()
+
+	balanceEntryRepo := mocks.NewMockBalanceEntryRepositoryInterface(ctrl)
+	balanceEntryRepo.EXPECT().Store(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	cfg := &config.Config{
+		PollInterval:      5 * time.Millisecond,
+		RateLimit:         2,
+		AgentOrderLimit:   10,
+		AgentMaxAttempts:  2,
+		AgentBackoffBase:  time.Millisecond,
+		AgentBackoffMax:   2 * time.Millisecond,
+		AgentLeaseTimeout: time.Minute,
+		ShutdownTimeout:   time.Second,
+	}
+	logger := zap.NewNop().Sugar()
+	service := services.NewAccrualService(
+		noopTxBeginner{},
+		jobRepo,
+		orderRepo,
+		userRepo,
+		balanceEntryRepo,
+		client,
+		cfg,
+		logger,
+	)
+	return NewSendOrderHandler(service, cfg, logger)
+}
+
+func TestSendUserOrders_DrivesOrdersThroughAccrual(t *testing.T) {
+	const (
+		polledOrder      = 1
+		rateLimitedOrder = 2
+		unknownOrder     = 3
+	)
+	store := newMemoryStore()
+	store.addOrder(polledOrder, 1001)
+	store.addOrder(rateLimitedOrder, 1002)
+	store.addOrder(unknownOrder, 1003)
+
+	client := fake.New().
+		Script(1001,
+			fake.Status("REGISTERED"),
+			fake.Status("PROCESSING").After(5*time.Millisecond),
+			fake.NetworkError(),
+			fake.Processed(money.FromMinorUnits(50000)),
+		).
+		Script(1002, fake.TooManyRequests(0), fake.Processed(money.FromMinorUnits(2550)))
+	handler := newAccrualHandlerUnderTest(t, store, client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- handler.SendUserOrders(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return store.order(polledOrder).StatusID == entities.StatusProcessed &&
+			store.order(rateLimitedOrder).StatusID == entities.StatusProcessed &&
+			store.order(unknownOrder).StatusID == entities.StatusInvalid
+	}, 2*time.Second, 5*time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("SendUserOrders did not stop after context cancellation")
+	}
+
+	assert.Nil(t, store.job(polledOrder))
+	assert.Nil(t, store.job(rateLimitedOrder))
+	deadJob := store.job(unknownOrder)
+	require.NotNil(t, deadJob)
+	assert.NotNil(t, deadJob.DeadAt)
+	assert.Equal(t, 2, deadJob.Attempts)
+
+	assert.Equal(t, 4, client.Calls(1001))
+	assert.Equal(t, 2, client.Calls(1002))
+	assert.Equal(t, 2, client.Calls(1003))
+	assert.Equal(t, money.FromMinorUnits(52550), store.balance(1))
+}
@@ -0,0 +1,504 @@
+package handlers
+
+import (
+	"context"
+	"gophermart/internal/app/entities"
+	"gophermart/internal/app/services/accrual"
+	"gophermart/internal/config"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+)
+
+type stubAccrualService struct {
+	onSend      func(ctx context.Context)
+	callbackErr error
+	pending     []entities.Job
+	sent        []int64
+	released    []int64
+	extended    []int64
+	lostLeases  map[int64]bool
+	callbacks   []*accrual.OrderResponse
+	reverified  []entities.ReverificationFilter
+	polls       int
+	mu          sync.Mutex
+}
+
+func (s *stubAccrualService) addJob(job entities.Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending = append(s.pending, job)
+}
+
+func (s *stubAccrualService) sentJobs() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int64(nil), s.sent...)
+}
+
+func (s *stubAccrualService) pollCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.polls
+}
+
+func (s *stubAccrualService) SendOrder(ctx context.Context, job *entities.Job) error {
+	if s.onSend != nil {
+		s.onSend(ctx)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, job.ID)
+	for i := range s.pending {
+		if s.pending[i].ID == job.ID {
+			s.pending = append(s.pending[:i], s.pending[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (s *stubAccrualService) releasedJobs() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int64(nil), s.released...)
+}
+
+func (s *stubAccrualService) ReleaseJob(_ context.Context, job *entities.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.released = append(s.released, job.ID)
+	return nil
+}
+
+func (s *stubAccrualService) extendedJobs() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int64(nil), s.extended...)
+}
+
+func (s *stubAccrualService) loseLease(jobID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lostLeases == nil {
+		s.lostLeases = make(map[int64]bool)
+	}
+	s.lostLeases[jobID] = true
+}
+
+func (s *stubAccrualService) ExtendLease(_ context.Context, job *entities.Job) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.extended = append(s.extended, job.ID)
+	return !s.lostLeases[job.ID], nil
+}
+
+func (s *stubAccrualService) ApplyCallback(_ context.Context, _ int64, orderResponse *accrual.OrderResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.callbacks = append(s.callbacks, orderResponse)
+	return s.callbackErr
+}
+
+func (s *stubAccrualService) EnqueueReverification(
+	_ context.Context,
+	filter entities.ReverificationFilter,
+) (entities.ReverificationResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reverified = append(s.reverified, filter)
+	return entities.ReverificationResult{Enqueued: int64(len(filter.UserIDs)), Skipped: 1}, nil
+}
+
+func (s *stubAccrualService) ClaimJobs(_ context.Context, limit int) ([]entities.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.polls++
+	if len(s.pending) > limit {
+		return append([]entities.Job(nil), s.pending[:limit]...), nil
+	}
+	return append([]entities.Job(nil), s.pending...), nil
+}
+
+func newTestSendOrderHandler(service *stubAccrualService) *SendOrderHandler {
+	cfg := &config.Config{
+		PollInterval:      10 * time.Millisecond,
+		RateLimit:         2,
+		AgentOrderLimit:   10,
+		AgentLeaseTimeout: time.Minute,
+		ShutdownTimeout:   time.Second,
+	}
+	return NewSendOrderHandler(service, cfg, zap.NewNop().Sugar())
+}
+
+func TestSendUserOrders_PicksUpJobsAddedAfterStart(t *testing.T) {
+	service := &stubAccrualService{}
+	handler := newTestSendOrderHandler(service)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- handler.SendUserOrders(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return service.pollCount() >= 3
+	}, time.Second, 5*time.Millisecond)
+
+	service.addJob(entities.Job{ID: 1, OrderID: 10})
+	require.Eventually(t, func() bool {
+		return len(service.sentJobs()) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	service.addJob(entities.Job{ID: 2, OrderID: 20})
+	service.addJob(entities.Job{ID: 3, OrderID: 30})
+	require.Eventually(t, func() bool {
+		return len(service.sentJobs()) == 3
+	}, time.Second, 5*time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("SendUserOrders did not stop after context cancellation")
+	}
+	assert.ElementsMatch(t, []int64{1, 2, 3}, service.sentJobs())
+}
+
+func TestSendUserOrders_StopsOnCancelledContext(t *testing.T) {
+	service := &stubAccrualService{}
+	handler := newTestSendOrderHandler(service)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- handler.SendUserOrders(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("SendUserOrders did not stop after context cancellation")
+	}
+	assert.Empty(t, service.sentJobs())
+}
+
+func TestSendUserOrders_FinishesInFlightJobOnShutdown(t *testing.T) {
+	started := make(chan struct{})
+	proceed := make(chan struct{})
+	var jobCtxErr error
+	service := &stubAccrualService{
+		onSend: func(ctx context.Context) {
+			close(started)
+			<-proceed
+			jobCtxErr = ctx.Err()
+		},
+	}
+	service.addJob(entities.Job{ID: 1, OrderID: 10})
+	handler := newTestSendOrderHandler(service)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- handler.SendUserOrders(ctx)
+	}()
+
+	<-started
+	cancel()
+	close(proceed)
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("SendUserOrders did not stop after context cancellation")
+	}
+	assert.NoError(t, jobCtxErr)
+	assert.Equal(t, []int64{1}, service.sentJobs())
+}
+
+func TestSendUserOrders_ReleasesUnprocessedLeasesOnShutdown(t *testing.T) {
+	var started sync.WaitGroup
+	started.Add(2)
+	proceed := make(chan struct{})
+	service := &stubAccrualService{
+		onSend: func(context.Context) {
+			started.Done()
+			<-proceed
+		},
+	}
+	for i := range 6 {
+		service.addJob(entities.Job{ID: int64(i + 1), OrderID: int64(10 * (i + 1))})
+	}
+	handler := newTestSendOrderHandler(service)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- handler.SendUserOrders(ctx)
+	}()
+
+	// оба воркера заняты, остальные задания ждут в очереди
+	started.Wait()
+	cancel()
+	close(proceed)
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("SendUserOrders did not stop after context cancellation")
+	}
+	sent := service.sentJobs()
+	released := service.releasedJobs()
+	assert.Len(t, sent, 2)
+	assert.Len(t, released, 4)
+	assert.ElementsMatch(t, []int64{1, 2, 3, 4, 5, 6}, append(sent, released...))
+}
+
+func TestSendUserOrders_KeepsLeaseOfInFlightJobOnShutdown(t *testing.T) {
+	started := make(chan struct{})
+	proceed := make(chan struct{})
+	service := &stubAccrualService{
+		onSend: func(context.Context) {
+			select {
+			case <-started:
+			default:
+				close(started)
+			}
+			<-proceed
+		},
+	}
+	service.addJob(entities.Job{ID: 1, OrderID: 10})
+	handler := newTestSendOrderHandler(service)
+	handler.Cfg.RateLimit = 1
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- handler.SendUserOrders(ctx)
+	}()
+
+	// аренда задания 1 истекла, пока оно обрабатывается, и очередь снова выдаёт его после новых заданий
+	<-started
+	service.mu.Lock()
+	service.pending = append([]entities.Job{{ID: 2, OrderID: 20}, {ID: 3, OrderID: 30}, {ID: 4, OrderID: 40}},
+		service.pending...)
+	polls := service.polls
+	service.mu.Unlock()
+	require.Eventually(t, func() bool { return service.pollCount() > polls }, time.Second, 5*time.Millisecond)
+	cancel()
+	close(proceed)
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("SendUserOrders did not stop after context cancellation")
+	}
+	assert.Equal(t, []int64{1}, service.sentJobs())
+	assert.ElementsMatch(t, []int64{2, 3, 4}, service.releasedJobs())
+}
+
+func TestSendUserOrders_ReconfigureResizesWorkerPool(t *testing.T) {
+	var active atomic.Int32
+	proceed := make(chan struct{})
+	service := &stubAccrualService{
+		onSend: func(context.Context) {
+			active.Add(1)
+			<-proceed
+		},
+	}
+	for i := range 4 {
+		service.addJob(entities.Job{ID: int64(i + 1), OrderID: int64(10 * (i + 1))})
+	}
+	handler := newTestSendOrderHandler(service)
+	handler.Cfg.RateLimit = 1
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- handler.SendUserOrders(ctx)
+	}()
+
+	require.Eventually(t, func() bool { return active.Load() == 1 }, time.Second, 5*time.Millisecond)
+	grown := *handler.Cfg
+	grown.RateLimit = 3
+	handler.Reconfigure(&grown)
+	require.Eventually(t, func() bool { return active.Load() == 3 }, time.Second, 5*time.Millisecond)
+
+	// уменьшение пула не прерывает задания, которые уже обрабатываются
+	shrunk := *handler.Cfg
+	shrunk.RateLimit = 1
+	handler.Reconfigure(&shrunk)
+	close(proceed)
+	require.Eventually(t, func() bool { return len(service.sentJobs()) == 4 }, time.Second, 5*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.ElementsMatch(t, []int64{1, 2, 3, 4}, service.sentJobs())
+	assert.Empty(t, service.releasedJobs())
+}
+
+func TestSendUserOrders_ReconfigureAppliesPollInterval(t *testing.T) {
+	service := &stubAccrualService{}
+	handler := newTestSendOrderHandler(service)
+	handler.Cfg.PollInterval = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- handler.SendUserOrders(ctx)
+	}()
+
+	faster := *handler.Cfg
+	faster.PollInterval = 10 * time.Millisecond
+	faster.AgentOrderLimit = 1
+	handler.Reconfigure(&faster)
+	service.addJob(entities.Job{ID: 1, OrderID: 10})
+	service.addJob(entities.Job{ID: 2, OrderID: 20})
+
+	require.Eventually(t, func() bool { return len(service.sentJobs()) == 2 }, time.Second, 5*time.Millisecond)
+	assert.GreaterOrEqual(t, service.pollCount(), 2)
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestSendUserOrders_LinksJobTraceToUploadRequest(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+
+	uploadTraceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	traceParent := "00-" + uploadTraceID + "-00f067aa0ba902b7-01"
+	service := &stubAccrualService{}
+	handler := newTestSendOrderHandler(service)
+	service.addJob(entities.Job{ID: 1, OrderID: 10, TraceParent: &traceParent})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- handler.SendUserOrders(ctx)
+	}()
+	require.Eventually(t, func() bool {
+		return len(service.sentJobs()) == 1
+	}, time.Second, 5*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+
+	var spans []sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "SendOrderHandler.process" {
+			spans = append(spans, span)
+		}
+	}
+	require.Len(t, spans, 1)
+	span := spans[0]
+	// обработка идёт в своей трассе, а запрос загрузки доступен по ссылке
+	assert.False(t, span.Parent().IsValid())
+	assert.NotEqual(t, uploadTraceID, span.SpanContext().TraceID().String())
+	require.Len(t, span.Links(), 1)
+	assert.Equal(t, uploadTraceID, span.Links()[0].SpanContext.TraceID().String())
+}
+
+func TestSendUserOrders_SkipsJobLeasedByAnotherWorker(t *testing.T) {
+	service := &stubAccrualService{lostLeases: map[int64]bool{1: true}}
+	service.addJob(entities.Job{ID: 1, OrderID: 10})
+	service.addJob(entities.Job{ID: 2, OrderID: 20})
+	handler := newTestSendOrderHandler(service)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- handler.SendUserOrders(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return len(service.sentJobs()) == 1 && slices.Contains(service.extendedJobs(), 1)
+	}, time.Second, 5*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+
+	assert.Equal(t, []int64{2}, service.sentJobs())
+}
+
+func TestSendUserOrders_RenewsLeaseWhileSending(t *testing.T) {
+	proceed := make(chan struct{})
+	service := &stubAccrualService{
+		onSend: func(context.Context) {
+			<-proceed
+		},
+	}
+	service.addJob(entities.Job{ID: 1, OrderID: 10})
+	handler := newTestSendOrderHandler(service)
+	handler.Cfg.AgentLeaseTimeout = 20 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- handler.SendUserOrders(ctx)
+	}()
+
+	// первое продление — перед отправкой, остальные — пока запрос ждёт ответа
+	require.Eventually(t, func() bool { return len(service.extendedJobs()) >= 3 }, time.Second, 5*time.Millisecond)
+	close(proceed)
+	require.Eventually(t, func() bool { return len(service.sentJobs()) == 1 }, time.Second, 5*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+
+	renewals := len(service.extendedJobs())
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, renewals, len(service.extendedJobs()), "renewal stops once the job is done")
+}
+
+func TestSendUserOrders_CancelsSendWhenLeaseIsLost(t *testing.T) {
+	started := make(chan struct{})
+	sendErr := make(chan error, 1)
+	service := &stubAccrualService{
+		onSend: func(ctx context.Context) {
+			close(started)
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+			sendErr <- ctx.Err()
+		},
+	}
+	service.addJob(entities.Job{ID: 1, OrderID: 10})
+	handler := newTestSendOrderHandler(service)
+	handler.Cfg.AgentLeaseTimeout = 20 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- handler.SendUserOrders(ctx)
+	}()
+
+	// аренду перехватил другой экземпляр, пока запрос ждёт ответа
+	<-started
+	service.loseLease(1)
+
+	assert.ErrorIs(t, <-sendErr, context.Canceled)
+	cancel()
+	require.NoError(t, <-done)
+}
@@ -7,27 +7,28 @@ import (
 	"gophermart/internal/app/dto"
 	"gophermart/internal/app/entities"
 	"gophermart/internal/app/services"
+	"gophermart/internal/app/utils"
 	"net/http"
 
 	"go.uber.org/zap"
 )
 
 type UserHandler struct {
-	UserService services.UserService
-	JwtService  services.JwtService
-	Logger      *zap.SugaredLogger
+	UserService    services.UserService
+	SessionService services.SessionService
+	Logger         *zap.SugaredLogger
 }
 
 func NewUserHandler(
 	userService services.UserService,
-	jwtService services.JwtService,
+	sessionService services.SessionService,
 	logger *zap.SugaredLogger,
 ) *UserHandler {
 	handlerLogger := logger.With("component:NewUserHandler", "UserHandler")
 	return &UserHandler{
-		UserService: userService,
-		JwtService:  jwtService,
-		Logger:      handlerLogger,
+		UserService:    userService,
+		SessionService: sessionService,
+		Logger:         handlerLogger,
 	}
 }
 
@@ -48,28 +49,13 @@ func (u *UserHandler) LoginUser() http.HandlerFunc {
 			return
 		}
 
-		tokenString, err := u.JwtService.CreateJwt(user.ID)
+		pair, err := u.SessionService.Start(ctx, user.ID)
 		if err != nil {
-			u.Logger.Infoln("error CreateJwt", err)
+			utils.ContextLogger(request.Context(), u.Logger).Infoln("error Start session", err)
 			response.WriteHeader(http.StatusInternalServerError)
 			return
 		}
-		cookie := &http.Cookie{
-			Name:     "Authorization",
-			Value:    tokenString,
-			Path:     "/",
-			HttpOnly: false,
-			Secure:   false,
-		}
-		http.SetCookie(response, cookie)
-		response.Header().Set("Authorization", tokenString)
-		err = json.NewEncoder(response).Encode(map[string]string{"token": tokenString})
-		if err != nil {
-			u.Logger.Infoln("error Encode token", err)
-			response.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-		response.WriteHeader(http.StatusOK)
+		u.writeTokens(response, request, pair)
 	}
 }
 
@@ -90,34 +76,86 @@ func (u *UserHandler) RegisterUser() http.HandlerFunc {
 				// логин уже занят;
 				response.WriteHeader(http.StatusConflict)
 			}
-			u.Logger.Infoln("error Register", err)
+			utils.ContextLogger(request.Context(), u.Logger).Infoln("error Register", err)
 			response.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 
-		tokenString, err := u.JwtService.CreateJwt(user.ID)
+		pair, err := u.SessionService.Start(ctx, user.ID)
 		if err != nil {
-			u.Logger.Infoln("error CreateJwt", err)
+			utils.ContextLogger(request.Context(), u.Logger).Infoln("error Start session", err)
 			response.WriteHeader(http.StatusInternalServerError)
 			return
 		}
+		u.writeTokens(response, request, pair)
+	}
+}
 
-		cookie := &http.Cookie{
-			Name:     "Authorization",
-			Value:    tokenString,
-			Path:     "/",
-			HttpOnly: false,
-			Secure:   false,
+// RefreshToken меняет токен обновления на новую пару токенов.
+func (u *UserHandler) RefreshToken() http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		var req dto.RefreshRequestBody
+		if err := json.NewDecoder(request.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+			response.WriteHeader(http.StatusBadRequest)
+			return
 		}
-		http.SetCookie(response, cookie)
-		response.Header().Set("Authorization", tokenString)
 
-		err = json.NewEncoder(response).Encode(map[string]string{"token": tokenString})
+		pair, err := u.SessionService.Refresh(request.Context(), req.RefreshToken)
 		if err != nil {
-			u.Logger.Infoln("error Encode token", err)
+			if errors.Is(err, apperrors.ErrRefreshTokenReused) {
+				utils.ContextLogger(request.Context(), u.Logger).Warnln("refresh token reuse, session revoked:", err)
+			}
+			if errors.Is(err, apperrors.ErrInvalidRefreshToken) || errors.Is(err, apperrors.ErrRefreshTokenReused) {
+				response.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			utils.ContextLogger(request.Context(), u.Logger).Infoln("error Refresh", err)
 			response.WriteHeader(http.StatusInternalServerError)
 			return
 		}
+		u.writeTokens(response, request, pair)
+	}
+}
+
+// Logout отзывает текущую сессию вместе со всеми её токенами.
+func (u *UserHandler) Logout() http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+		sessionID, err := utils.GetSessionID(ctx)
+		if err != nil {
+			response.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if err = u.SessionService.Revoke(ctx, sessionID); err != nil {
+			utils.ContextLogger(request.Context(), u.Logger).Infoln("error Revoke session", err)
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		http.SetCookie(response, &http.Cookie{
+			Name:   "Authorization",
+			Value:  "",
+			Path:   "/",
+			MaxAge: -1,
+		})
 		response.WriteHeader(http.StatusOK)
 	}
 }
+
+func (u *UserHandler) writeTokens(response http.ResponseWriter, request *http.Request, pair *dto.TokenPair) {
+	cookie := &http.Cookie{
+		Name:     "Authorization",
+		Value:    pair.AccessToken,
+		Path:     "/",
+		HttpOnly: false,
+		Secure:   false,
+	}
+	http.SetCookie(response, cookie)
+	response.Header().Set("Authorization", pair.AccessToken)
+	response.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(response).Encode(pair)
+	if err != nil {
+		utils.ContextLogger(request.Context(), u.Logger).Infoln("error Encode token", err)
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
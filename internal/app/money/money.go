@@ -0,0 +1,204 @@
+package money
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Money — сумма в баллах, хранящаяся в сотых долях (минимальных единицах) без потери точности.
+type Money int64
+
+const (
+	fractionDigits = 2
+	minorUnits     = 100
+	// maxInputLength ограничивает разбираемую запись, чтобы не строить огромные big.Rat.
+	maxInputLength = 64
+)
+
+var (
+	ErrInvalidAmount = errors.New("invalid money amount")
+	ErrOutOfRange    = errors.New("money amount out of range")
+	ErrTooPrecise    = errors.New("money amount has more than two decimal places")
+)
+
+// Порядок ограничен тремя цифрами: суммы больше 1e18 всё равно не помещаются в Money.
+var decimalPattern = regexp.MustCompile(`^[+-]?\d+(\.\d+)?([eE][+-]?\d{1,3})?$`)
+
+func FromMinorUnits(units int64) Money {
+	return Money(units)
+}
+
+// Parse разбирает десятичную запись суммы; цифры после сотых округляются половиной от нуля.
+func Parse(s string) (Money, error) {
+	r, err := parseRat(s)
+	if err != nil {
+		return 0, err
+	}
+	return fromRat(r)
+}
+
+// ParseExact разбирает сумму, введённую пользователем: цифры после сотых не округляются, а отклоняются.
+func ParseExact(s string) (Money, error) {
+	r, err := parseRat(s)
+	if err != nil {
+		return 0, err
+	}
+	if !new(big.Rat).Mul(r, big.NewRat(minorUnits, 1)).IsInt() {
+		return 0, fmt.Errorf("%w: %q", ErrTooPrecise, s)
+	}
+	return fromRat(r)
+}
+
+func parseRat(s string) (*big.Rat, error) {
+	if len(s) > maxInputLength {
+		return nil, fmt.Errorf("%w: longer than %d characters", ErrInvalidAmount, maxInputLength)
+	}
+	if !decimalPattern.MatchString(s) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	return r, nil
+}
+
+func (m Money) MinorUnits() int64 {
+	return int64(m)
+}
+
+func (m Money) Float64() float64 {
+	return float64(m) / minorUnits
+}
+
+func (m Money) String() string {
+	sign := ""
+	abs := uint64(m)
+	if m < 0 {
+		sign = "-"
+		abs = uint64(-m)
+	}
+	whole := abs / minorUnits
+	fraction := abs % minorUnits
+	switch {
+	case fraction == 0:
+		return sign + strconv.FormatUint(whole, 10)
+	case fraction%10 == 0:
+		return fmt.Sprintf("%s%d.%d", sign, whole, fraction/10)
+	default:
+		return fmt.Sprintf("%s%d.%02d", sign, whole, fraction)
+	}
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	return m.unmarshalJSON(data, Parse)
+}
+
+// UnmarshalExactJSON разбирает сумму из JSON так же, как ParseExact.
+func (m *Money) UnmarshalExactJSON(data []byte) error {
+	return m.unmarshalJSON(data, ParseExact)
+}
+
+func (m *Money) unmarshalJSON(data []byte, parse func(string) (Money, error)) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) > 1 && data[0] == '"' {
+		unquoted, err := strconv.Unquote(string(data))
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidAmount, data)
+		}
+		data = []byte(unquoted)
+	}
+	parsed, err := parse(string(data))
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+func (m *Money) ScanNumeric(v pgtype.Numeric) error {
+	if !v.Valid {
+		return fmt.Errorf("%w: cannot scan NULL into Money", ErrInvalidAmount)
+	}
+	parsed, err := fromNumeric(v)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+func (m Money) NumericValue() (pgtype.Numeric, error) {
+	return pgtype.Numeric{Int: big.NewInt(int64(m)), Exp: -fractionDigits, Valid: true}, nil
+}
+
+// SkipUnderlyingTypePlan не даёт pgx кодировать Money как обычное целое число.
+func (m Money) SkipUnderlyingTypePlan() {}
+
+type NullMoney struct {
+	Money Money
+	Valid bool
+}
+
+func (n *NullMoney) ScanNumeric(v pgtype.Numeric) error {
+	if !v.Valid {
+		*n = NullMoney{}
+		return nil
+	}
+	parsed, err := fromNumeric(v)
+	if err != nil {
+		return err
+	}
+	*n = NullMoney{Money: parsed, Valid: true}
+	return nil
+}
+
+func (n NullMoney) NumericValue() (pgtype.Numeric, error) {
+	if !n.Valid {
+		return pgtype.Numeric{}, nil
+	}
+	return n.Money.NumericValue()
+}
+
+func fromNumeric(v pgtype.Numeric) (Money, error) {
+	if v.NaN || v.InfinityModifier != pgtype.Finite {
+		return 0, fmt.Errorf("%w: non-finite numeric", ErrInvalidAmount)
+	}
+	r := new(big.Rat).SetInt(v.Int)
+	exp := int64(v.Exp)
+	if exp < 0 {
+		exp = -exp
+	}
+	pow := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(exp), nil))
+	if v.Exp >= 0 {
+		r.Mul(r, pow)
+	} else {
+		r.Quo(r, pow)
+	}
+	return fromRat(r)
+}
+
+func fromRat(r *big.Rat) (Money, error) {
+	scaled := new(big.Rat).Mul(r, big.NewRat(minorUnits, 1))
+	quo, rem := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	// округление половиной от нуля: |rem| * 2 >= denom
+	if rem.Sign() != 0 && new(big.Int).Lsh(new(big.Int).Abs(rem), 1).Cmp(scaled.Denom()) >= 0 {
+		quo.Add(quo, big.NewInt(int64(rem.Sign())))
+	}
+	if !quo.IsInt64() {
+		return 0, ErrOutOfRange
+	}
+	return Money(quo.Int64()), nil
+}
@@ -0,0 +1,163 @@
+package money
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Money
+		wantErr  bool
+	}{
+		{input: "500", expected: 50000},
+		{input: "500.5", expected: 50050},
+		{input: "729.98", expected: 72998},
+		{input: "0.005", expected: 1},
+		{input: "0.004", expected: 0},
+		{input: "-0.005", expected: -1},
+		{input: "1e2", expected: 10000},
+		{input: "1/3", wantErr: true},
+		{input: "0x10", wantErr: true},
+		{input: "abc", wantErr: true},
+		{input: "", wantErr: true},
+		{input: "99999999999999999999", wantErr: true},
+		{input: "1e999", wantErr: true},
+		{input: "1e-999", expected: 0},
+		{input: "1e9999", wantErr: true},
+		{input: "1" + strings.Repeat("0", 64), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := Parse(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestParse_OutOfRangeOmitsValue(t *testing.T) {
+	_, err := Parse("1e999")
+
+	require.ErrorIs(t, err, ErrOutOfRange)
+	assert.Equal(t, ErrOutOfRange.Error(), err.Error())
+}
+
+func TestParseExact(t *testing.T) {
+	tests := []struct {
+		err      error
+		input    string
+		expected Money
+	}{
+		{input: "729.98", expected: 72998},
+		{input: "729.980", expected: 72998},
+		{input: "1e-2", expected: 1},
+		{input: "0.005", err: ErrTooPrecise},
+		{input: "1e-999", err: ErrTooPrecise},
+		{input: "abc", err: ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := ParseExact(tt.input)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		expected string
+		input    Money
+	}{
+		{input: 50000, expected: "500"},
+		{input: 50050, expected: "500.5"},
+		{input: 72998, expected: "729.98"},
+		{input: 1, expected: "0.01"},
+		{input: -150, expected: "-1.5"},
+		{input: 0, expected: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.input.String())
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	type body struct {
+		Accrual *Money `json:"accrual,omitempty"`
+		Sum     Money  `json:"sum"`
+	}
+
+	var decoded body
+	require.NoError(t, json.Unmarshal([]byte(`{"sum": 751.1, "accrual": 0.1}`), &decoded))
+	assert.Equal(t, Money(75110), decoded.Sum)
+	require.NotNil(t, decoded.Accrual)
+	assert.Equal(t, Money(10), *decoded.Accrual)
+
+	encoded, err := json.Marshal(body{Sum: 50050})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"sum": 500.5}`, string(encoded))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"sum": "abc"}`), &decoded))
+}
+
+func TestNoDriftOnRepeatedOperations(t *testing.T) {
+	var balance Money
+	tenth, err := Parse("0.1")
+	require.NoError(t, err)
+	for range 1000 {
+		balance += tenth
+	}
+	for range 999 {
+		balance -= tenth
+	}
+	assert.Equal(t, "0.1", balance.String())
+}
+
+func TestPostgresNumericRoundTrip(t *testing.T) {
+	m := pgtype.NewMap()
+
+	for _, format := range []int16{pgtype.TextFormatCode, pgtype.BinaryFormatCode} {
+		encoded, err := m.Encode(pgtype.NumericOID, format, Money(72998), nil)
+		require.NoError(t, err)
+
+		var scanned Money
+		require.NoError(t, m.Scan(pgtype.NumericOID, format, encoded, &scanned))
+		assert.Equal(t, Money(72998), scanned)
+
+		var nullable NullMoney
+		require.NoError(t, m.Scan(pgtype.NumericOID, format, encoded, &nullable))
+		assert.Equal(t, NullMoney{Money: 72998, Valid: true}, nullable)
+
+		require.NoError(t, m.Scan(pgtype.NumericOID, format, nil, &nullable))
+		assert.False(t, nullable.Valid)
+	}
+
+	var scanned Money
+	require.NoError(t, m.Scan(pgtype.NumericOID, pgtype.TextFormatCode, []byte("12.345"), &scanned))
+	assert.Equal(t, Money(1235), scanned)
+	assert.Error(t, m.Scan(pgtype.NumericOID, pgtype.TextFormatCode, nil, &scanned))
+
+	encoded, err := m.Encode(pgtype.NumericOID, pgtype.TextFormatCode, NullMoney{}, nil)
+	require.NoError(t, err)
+	assert.Nil(t, encoded)
+}
@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"gophermart/internal/app/entities"
+	"gophermart/internal/app/money"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type BalanceEntryRepositoryInterface interface {
+	Store(ctx context.Context, tx pgx.Tx, entry *entities.BalanceEntry) error
+	GetWithdrawnByUserID(ctx context.Context, userID int) (money.Money, error)
+	GetMismatches(ctx context.Context) ([]entities.BalanceMismatch, error)
+}
+
+type balanceEntryRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewBalanceEntryRepository(db *pgxpool.Pool) BalanceEntryRepositoryInterface {
+	return &balanceEntryRepository{
+		Pool: db,
+	}
+}
+
+func (r *balanceEntryRepository) Store(ctx context.Context, tx pgx.Tx, entry *entities.BalanceEntry) error {
+	query := `
+		INSERT INTO balance_entries (user_id, type_id, amount, order_id, withdraw_id, reason)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	args := []any{entry.UserID, entry.TypeID, entry.Amount, entry.OrderID, entry.WithdrawID, entry.Reason}
+
+	var err error
+	if tx != nil {
+		err = tx.QueryRow(ctx, query, args...).Scan(&entry.ID, &entry.CreatedAt)
+	} else {
+		err = r.Pool.QueryRow(ctx, query, args...).Scan(&entry.ID, &entry.CreatedAt)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to save balance entry for user %d: %w", entry.UserID, err)
+	}
+
+	return nil
+}
+
+func (r *balanceEntryRepository) GetWithdrawnByUserID(ctx context.Context, userID int) (money.Money, error) {
+	query := `
+		SELECT COALESCE(-SUM(amount), 0)
+		FROM balance_entries
+		WHERE user_id = $1 AND type_id = $2
+	`
+
+	var withdrawn money.Money
+	err := r.Pool.QueryRow(ctx, query, userID, entities.EntryTypeWithdrawal).Scan(&withdrawn)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get withdrawn total for user %d: %w", userID, err)
+	}
+
+	return withdrawn, nil
+}
+
+func (r *balanceEntryRepository) GetMismatches(ctx context.Context) ([]entities.BalanceMismatch, error) {
+	query := `
+		SELECT u.id, u.balance, COALESCE(SUM(e.amount), 0)
+		FROM users u
+		LEFT JOIN balance_entries e ON e.user_id = u.id
+		GROUP BY u.id, u.balance
+		HAVING u.balance <> COALESCE(SUM(e.amount), 0)
+		ORDER BY u.id
+	`
+	rows, err := r.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile balances: %w", err)
+	}
+	defer rows.Close()
+
+	var mismatches []entities.BalanceMismatch
+	for rows.Next() {
+		var mismatch entities.BalanceMismatch
+		err = rows.Scan(&mismatch.UserID, &mismatch.Cached, &mismatch.Ledger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse reconcile result: %w", err)
+		}
+		mismatches = append(mismatches, mismatch)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to reconcile balances: %w", err)
+	}
+
+	return mismatches, nil
+}
@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gophermart/internal/app/entities"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IdempotencyRepositoryInterface interface {
+	Claim(ctx context.Context, key *entities.IdempotencyKey, ttl time.Duration) (bool, error)
+	GetByKey(ctx context.Context, userID int64, key string) (*entities.IdempotencyKey, error)
+	SaveResponse(ctx context.Context, key *entities.IdempotencyKey) error
+	Delete(ctx context.Context, userID int64, key string) error
+}
+
+type idempotencyRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewIdempotencyRepository(db *pgxpool.Pool) IdempotencyRepositoryInterface {
+	return &idempotencyRepository{
+		Pool: db,
+	}
+}
+
+// Claim резервирует ключ за запросом. Ключ, созданный раньше ttl, считается истёкшим и резервируется заново.
+func (r *idempotencyRepository) Claim(
+	ctx context.Context,
+	key *entities.IdempotencyKey,
+	ttl time.Duration,
+) (bool, error) {
+	query := `
+		INSERT INTO idempotency_keys (user_id, idempotency_key, fingerprint)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, idempotency_key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint,
+			status_code = NULL,
+			content_type = NULL,
+			response_body = NULL,
+			created_at = now(),
+			completed_at = NULL
+		WHERE idempotency_keys.created_at < now() - make_interval(secs => $4)
+		RETURNING created_at
+	`
+	err := r.Pool.QueryRow(ctx, query, key.UserID, key.Key, key.Fingerprint, ttl.Seconds()).Scan(&key.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+	return true, nil
+}
+
+func (r *idempotencyRepository) GetByKey(
+	ctx context.Context,
+	userID int64,
+	key string,
+) (*entities.IdempotencyKey, error) {
+	query := `
+		SELECT user_id, idempotency_key, fingerprint, COALESCE(status_code, 0),
+			COALESCE(content_type, ''), response_body, created_at, completed_at
+		FROM idempotency_keys
+		WHERE user_id = $1 AND idempotency_key = $2
+	`
+	var stored entities.IdempotencyKey
+	err := r.Pool.QueryRow(ctx, query, userID, key).Scan(
+		&stored.UserID,
+		&stored.Key,
+		&stored.Fingerprint,
+		&stored.StatusCode,
+		&stored.ContentType,
+		&stored.ResponseBody,
+		&stored.CreatedAt,
+		&stored.CompletedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	return &stored, nil
+}
+
+func (r *idempotencyRepository) SaveResponse(ctx context.Context, key *entities.IdempotencyKey) error {
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = $1, content_type = $2, response_body = $3, completed_at = now()
+		WHERE user_id = $4 AND idempotency_key = $5
+	`
+	_, err := r.Pool.Exec(ctx, query, key.StatusCode, key.ContentType, key.ResponseBody, key.UserID, key.Key)
+	if err != nil {
+		return fmt.Errorf("failed to save idempotent response: %w", err)
+	}
+	return nil
+}
+
+func (r *idempotencyRepository) Delete(ctx context.Context, userID int64, key string) error {
+	query := `
+		DELETE FROM idempotency_keys
+		WHERE user_id = $1 AND idempotency_key = $2
+	`
+	_, err := r.Pool.Exec(ctx, query, userID, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete idempotency key: %w", err)
+	}
+	return nil
+}
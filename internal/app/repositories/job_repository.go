@@ -3,19 +3,28 @@ package repositories
 import (
 	"context"
 	"fmt"
+	"gophermart/internal/app/apperrors"
 	"gophermart/internal/app/entities"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type JobRepositoryInterface interface {
-	GetPendingJobs(ctx context.Context, tx pgx.Tx, limit int) ([]entities.Job, error)
+	ClaimJobs(ctx context.Context, workerID string, limit int, lease time.Duration) ([]entities.Job, error)
+	ReleaseJob(ctx context.Context, tx pgx.Tx, jobID int64, workerID string) error
+	ExtendLease(ctx context.Context, jobID int64, workerID string, lease time.Duration) (bool, error)
 	SaveJob(ctx context.Context, tx pgx.Tx, job *entities.Job) error
-	UpdateJobPoolAt(ctx context.Context, tx pgx.Tx, jobID int64) error
+	ScheduleRetry(ctx context.Context, tx pgx.Tx, job *entities.Job, workerID string) error
+	MarkJobDead(ctx context.Context, tx pgx.Tx, job *entities.Job, workerID string) error
+	ScheduleNextPoll(ctx context.Context, tx pgx.Tx, job *entities.Job, workerID string) error
 	DeleteJobByID(ctx context.Context, tx pgx.Tx, jobID int64) error
+	DeleteJobByOrderID(ctx context.Context, tx pgx.Tx, orderID int64) error
+	EnqueueReverification(ctx context.Context, filter entities.ReverificationFilter) (entities.ReverificationResult, error)
+	CountJobs(ctx context.Context) (pending int64, dead int64, err error)
 }
 
 type jobRepository struct {
@@ -28,25 +37,34 @@ func NewJobRepository(db *pgxpool.Pool) JobRepositoryInterface {
 	}
 }
 
-func (r *jobRepository) GetPendingJobs(ctx context.Context, tx pgx.Tx, limit int) ([]entities.Job, error) {
+// ClaimJobs берёт в аренду свободные задания или задания с истёкшей арендой.
+// SKIP LOCKED позволяет нескольким экземплярам сервиса разбирать очередь, не мешая друг другу.
+func (r *jobRepository) ClaimJobs(
+	ctx context.Context,
+	workerID string,
+	limit int,
+	lease time.Duration,
+) ([]entities.Job, error) {
 	query := `
-		SELECT id, order_id, created_at, pool_at
-		FROM jobs
-		ORDER BY created_at ASC, pool_at ASC
-		LIMIT $1
+		UPDATE jobs
+		SET locked_until = now() + make_interval(secs => $3), worker_id = $2
+		WHERE id IN (
+			SELECT id
+			FROM jobs
+			WHERE dead_at IS NULL
+				AND (next_attempt_at IS NULL OR next_attempt_at <= now())
+				AND (locked_until IS NULL OR locked_until < now())
+			ORDER BY created_at ASC, pool_at ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, order_id, created_at, pool_at, locked_until, worker_id,
+			attempts, next_attempt_at, last_error, last_error_category, polls, trace_parent, reverify
 	`
 
-	var rows pgx.Rows
-	var err error
-
-	if tx != nil {
-		rows, err = tx.Query(ctx, query, limit)
-	} else {
-		rows, err = r.Pool.Query(ctx, query, limit)
-	}
-
+	rows, err := r.Pool.Query(ctx, query, limit, workerID, lease.Seconds())
 	if err != nil {
-		return nil, fmt.Errorf("failed to get pending jobs: %w", err)
+		return nil, fmt.Errorf("failed to claim jobs: %w", err)
 	}
 	defer rows.Close()
 
@@ -58,6 +76,15 @@ func (r *jobRepository) GetPendingJobs(ctx context.Context, tx pgx.Tx, limit int
 			&job.OrderID,
 			&job.CreatedAt,
 			&job.PoolAt,
+			&job.LockedUntil,
+			&job.WorkerID,
+			&job.Attempts,
+			&job.NextAttemptAt,
+			&job.LastError,
+			&job.LastErrorCategory,
+			&job.Polls,
+			&job.TraceParent,
+			&job.Reverify,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse job result %d: %w", job.ID, err)
@@ -66,24 +93,67 @@ func (r *jobRepository) GetPendingJobs(ctx context.Context, tx pgx.Tx, limit int
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("failed to get pending jobs: %w", err)
+		return nil, fmt.Errorf("failed to claim jobs: %w", err)
 	}
 
 	return jobs, nil
 }
 
+func (r *jobRepository) ReleaseJob(ctx context.Context, tx pgx.Tx, jobID int64, workerID string) error {
+	query := `
+		UPDATE jobs
+		SET locked_until = NULL, worker_id = NULL
+		WHERE id = $1 AND worker_id = $2
+	`
+
+	var err error
+	if tx != nil {
+		_, err = tx.Exec(ctx, query, jobID, workerID)
+	} else {
+		_, err = r.Pool.Exec(ctx, query, jobID, workerID)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to release job %d: %w", jobID, err)
+	}
+
+	return nil
+}
+
+// ExtendLease продлевает аренду задания, пока оно числится за workerID. false означает,
+// что аренду после истечения перехватил другой экземпляр или задание уже удалено.
+func (r *jobRepository) ExtendLease(
+	ctx context.Context,
+	jobID int64,
+	workerID string,
+	lease time.Duration,
+) (bool, error) {
+	query := `
+		UPDATE jobs
+		SET locked_until = now() + make_interval(secs => $3)
+		WHERE id = $1 AND worker_id = $2 AND dead_at IS NULL
+	`
+
+	tag, err := r.Pool.Exec(ctx, query, jobID, workerID, lease.Seconds())
+	if err != nil {
+		return false, fmt.Errorf("failed to extend lease of job %d: %w", jobID, err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
 func (r *jobRepository) SaveJob(ctx context.Context, tx pgx.Tx, job *entities.Job) error {
 	query := `
-		INSERT INTO jobs (order_id, created_at, pool_at)
-		VALUES ($1, $2, $3)
+		INSERT INTO jobs (order_id, created_at, pool_at, trace_parent)
+		VALUES ($1, $2, $3, $4)
 		RETURNING id
 	`
 
+	args := []any{job.OrderID, job.CreatedAt, job.PoolAt, job.TraceParent}
 	var err error
 	if tx != nil {
-		err = tx.QueryRow(ctx, query, job.OrderID, job.CreatedAt, job.PoolAt).Scan(&job.ID)
+		err = tx.QueryRow(ctx, query, args...).Scan(&job.ID)
 	} else {
-		err = r.Pool.QueryRow(ctx, query, job.OrderID, job.CreatedAt, job.PoolAt).Scan(&job.ID)
+		err = r.Pool.QueryRow(ctx, query, args...).Scan(&job.ID)
 	}
 
 	if err != nil {
@@ -93,23 +163,91 @@ func (r *jobRepository) SaveJob(ctx context.Context, tx pgx.Tx, job *entities.Jo
 	return nil
 }
 
-func (r *jobRepository) UpdateJobPoolAt(ctx context.Context, tx pgx.Tx, jobID int64) error {
+// ScheduleRetry сохраняет попытки, ошибку и время следующей попытки из job и снимает аренду,
+// чтобы задание не ждало её истечения. Если аренда истекла или перешла к другому воркеру,
+// возвращает ErrJobLeaseLost: решение о задании принимает его новый владелец.
+func (r *jobRepository) ScheduleRetry(ctx context.Context, tx pgx.Tx, job *entities.Job, workerID string) error {
 	query := `
 		UPDATE jobs
-		SET pool_at = $1
-		WHERE id = $2
+		SET pool_at = now(), attempts = $1, next_attempt_at = $2, last_error = $3, last_error_category = $4,
+			locked_until = NULL, worker_id = NULL
+		WHERE id = $5 AND worker_id = $6 AND locked_until > now()
 	`
 
+	args := []any{job.Attempts, job.NextAttemptAt, job.LastError, job.LastErrorCategory, job.ID, workerID}
+	var tag pgconn.CommandTag
+	var err error
 	if tx != nil {
-		_, err := tx.Exec(ctx, query, time.Now(), jobID)
-		if err != nil {
-			return fmt.Errorf("failed to update pool_at for job %d: %w", jobID, err)
-		}
+		tag, err = tx.Exec(ctx, query, args...)
 	} else {
-		_, err := r.Pool.Exec(ctx, query, time.Now(), jobID)
-		if err != nil {
-			return fmt.Errorf("failed to update pool_at for job %d: %w", jobID, err)
-		}
+		tag, err = r.Pool.Exec(ctx, query, args...)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to schedule retry for job %d: %w", job.ID, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("failed to schedule retry for job %d: %w", job.ID, apperrors.ErrJobLeaseLost)
+	}
+
+	return nil
+}
+
+// MarkJobDead переводит задание в dead-letter: оно остаётся в таблице, но больше не выдаётся воркерам.
+// Как и ScheduleRetry, требует действующей аренды workerID.
+func (r *jobRepository) MarkJobDead(ctx context.Context, tx pgx.Tx, job *entities.Job, workerID string) error {
+	query := `
+		UPDATE jobs
+		SET pool_at = now(), attempts = $1, last_error = $2, last_error_category = $3, dead_at = now(),
+			next_attempt_at = NULL, locked_until = NULL, worker_id = NULL
+		WHERE id = $4 AND worker_id = $5 AND locked_until > now()
+	`
+
+	args := []any{job.Attempts, job.LastError, job.LastErrorCategory, job.ID, workerID}
+	var tag pgconn.CommandTag
+	var err error
+	if tx != nil {
+		tag, err = tx.Exec(ctx, query, args...)
+	} else {
+		tag, err = r.Pool.Exec(ctx, query, args...)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to mark job %d as dead: %w", job.ID, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("failed to mark job %d as dead: %w", job.ID, apperrors.ErrJobLeaseLost)
+	}
+
+	return nil
+}
+
+// ScheduleNextPoll откладывает повторный опрос заказа, расчёт по которому ещё идёт.
+// Успешный ответ обнуляет счётчик ошибок: до dead-letter считаются только неудачи подряд.
+// Как и ScheduleRetry, требует действующей аренды workerID.
+func (r *jobRepository) ScheduleNextPoll(ctx context.Context, tx pgx.Tx, job *entities.Job, workerID string) error {
+	query := `
+		UPDATE jobs
+		SET pool_at = now(), polls = $1, next_attempt_at = $2,
+			attempts = 0, last_error = NULL, last_error_category = NULL,
+			locked_until = NULL, worker_id = NULL
+		WHERE id = $3 AND worker_id = $4 AND locked_until > now()
+	`
+
+	args := []any{job.Polls, job.NextAttemptAt, job.ID, workerID}
+	var tag pgconn.CommandTag
+	var err error
+	if tx != nil {
+		tag, err = tx.Exec(ctx, query, args...)
+	} else {
+		tag, err = r.Pool.Exec(ctx, query, args...)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to schedule next poll for job %d: %w", job.ID, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("failed to schedule next poll for job %d: %w", job.ID, apperrors.ErrJobLeaseLost)
 	}
 
 	return nil
@@ -135,3 +273,85 @@ func (r *jobRepository) DeleteJobByID(ctx context.Context, tx pgx.Tx, jobID int6
 
 	return nil
 }
+
+// DeleteJobByOrderID удаляет задание заказа, если оно ещё есть; у заказа не больше одного задания.
+func (r *jobRepository) DeleteJobByOrderID(ctx context.Context, tx pgx.Tx, orderID int64) error {
+	query := `
+		DELETE FROM jobs
+		WHERE order_id = $1
+	`
+
+	if tx != nil {
+		_, err := tx.Exec(ctx, query, orderID)
+		if err != nil {
+			return fmt.Errorf("failed to delete job for order %d: %w", orderID, err)
+		}
+	} else {
+		_, err := r.Pool.Exec(ctx, query, orderID)
+		if err != nil {
+			return fmt.Errorf("failed to delete job for order %d: %w", orderID, err)
+		}
+	}
+
+	return nil
+}
+
+// EnqueueReverification ставит в очередь обработанные заказы, подходящие под filter.
+// Только такие задания могут скорректировать уже проведённое начисление.
+// Задание из dead-letter возвращается в очередь с обнулёнными попытками,
+// а заказ, чьё задание ещё ждёт опроса, пропускается.
+func (r *jobRepository) EnqueueReverification(
+	ctx context.Context,
+	filter entities.ReverificationFilter,
+) (entities.ReverificationResult, error) {
+	query := `
+		WITH candidates AS (
+			SELECT id
+			FROM orders
+			WHERE status_id = $1
+				AND ($2::timestamp IS NULL OR created_at >= $2)
+				AND ($3::timestamp IS NULL OR created_at < $3)
+				AND (cardinality($4::bigint[]) = 0 OR user_id = ANY($4))
+		), upserted AS (
+			INSERT INTO jobs (order_id, reverify)
+			SELECT id, TRUE FROM candidates
+			ON CONFLICT (order_id) DO UPDATE
+			SET dead_at = NULL, attempts = 0, next_attempt_at = now(), worker_id = NULL, locked_until = NULL,
+				reverify = TRUE
+			WHERE jobs.dead_at IS NOT NULL
+			RETURNING xmax = 0 AS inserted
+		)
+		SELECT
+			count(*) FILTER (WHERE inserted),
+			count(*) FILTER (WHERE NOT inserted),
+			(SELECT count(*) FROM candidates) - count(*)
+		FROM upserted
+	`
+	userIDs := filter.UserIDs
+	if userIDs == nil {
+		userIDs = []int64{}
+	}
+	var result entities.ReverificationResult
+	err := r.Pool.QueryRow(ctx, query, entities.StatusProcessed, filter.From, filter.To, userIDs).
+		Scan(&result.Enqueued, &result.Revived, &result.Skipped)
+	if err != nil {
+		return entities.ReverificationResult{}, fmt.Errorf("failed to enqueue orders for reverification: %w", err)
+	}
+	return result, nil
+}
+
+// CountJobs считает задания, ожидающие опроса, и задания в dead-letter.
+func (r *jobRepository) CountJobs(ctx context.Context) (int64, int64, error) {
+	query := `
+		SELECT
+			count(*) FILTER (WHERE dead_at IS NULL),
+			count(*) FILTER (WHERE dead_at IS NOT NULL)
+		FROM jobs
+	`
+
+	var pending, dead int64
+	if err := r.Pool.QueryRow(ctx, query).Scan(&pending, &dead); err != nil {
+		return 0, 0, fmt.Errorf("failed to count jobs: %w", err)
+	}
+	return pending, dead, nil
+}
@@ -0,0 +1,336 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"gophermart/internal/app/apperrors"
+	"gophermart/internal/app/entities"
+	"gophermart/internal/store"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newIntegrationDB(t *testing.T) *store.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URI")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URI is not set")
+	}
+	db, err := store.NewDB(context.Background(), dsn)
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+	return db
+}
+
+func seedJobs(t *testing.T, db *store.DB, count int) map[int64]struct{} {
+	t.Helper()
+	ctx := context.Background()
+	userRepo := NewUserRepository(db.Pool)
+	orderRepo := NewOrderRepository(db.Pool)
+	jobRepo := NewJobRepository(db.Pool)
+
+	seed := time.Now().UnixNano()
+	user, err := userRepo.Store(ctx, entities.User{
+		Login:    fmt.Sprintf("job-claim-%d", seed),
+		Password: "password",
+	})
+	require.NoError(t, err)
+
+	orderIDs := make(map[int64]struct{}, count)
+	for i := range count {
+		order := &entities.Order{
+			OrderID:  int(seed%1_000_000_000) + i,
+			UserID:   int64(user.ID),
+			StatusID: entities.StatusNew,
+		}
+		id, err := orderRepo.Store(ctx, nil, order)
+		require.NoError(t, err)
+		require.NoError(t, jobRepo.SaveJob(ctx, nil, &entities.Job{OrderID: int64(id)}))
+		orderIDs[int64(id)] = struct{}{}
+	}
+	return orderIDs
+}
+
+func TestClaimJobs_ConcurrentWorkersNeverShareJobs(t *testing.T) {
+	db := newIntegrationDB(t)
+	ctx := context.Background()
+	jobRepo := NewJobRepository(db.Pool)
+
+	const (
+		jobs    = 30
+		workers = 5
+	)
+	orderIDs := seedJobs(t, db, jobs)
+
+	claimed := make(map[int64]string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			workerID := fmt.Sprintf("worker-%d", w)
+			for {
+				batch, err := jobRepo.ClaimJobs(ctx, workerID, 3, time.Minute)
+				if err != nil {
+					t.Errorf("claim jobs: %v", err)
+					return
+				}
+				if len(batch) == 0 {
+					return
+				}
+				mu.Lock()
+				for _, job := range batch {
+					if owner, ok := claimed[job.OrderID]; ok {
+						t.Errorf("order %d claimed by %s and %s", job.OrderID, owner, workerID)
+					}
+					claimed[job.OrderID] = workerID
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for orderID := range orderIDs {
+		assert.Contains(t, claimed, orderID)
+	}
+}
+
+func TestClaimJobs_ReclaimsExpiredLease(t *testing.T) {
+	db := newIntegrationDB(t)
+	ctx := context.Background()
+	jobRepo := NewJobRepository(db.Pool)
+	orderIDs := seedJobs(t, db, 1)
+
+	// первый экземпляр берёт задания в аренду и «умирает»
+	first := claimAll(t, jobRepo, "dead-worker", 50*time.Millisecond, orderIDs)
+	require.Len(t, first, 1)
+	assert.Empty(t, claimAll(t, jobRepo, "live-worker", time.Minute, orderIDs))
+
+	time.Sleep(100 * time.Millisecond)
+	second := claimAll(t, jobRepo, "live-worker", time.Minute, orderIDs)
+	require.Len(t, second, 1)
+	assert.Equal(t, first[0].ID, second[0].ID)
+	require.NotNil(t, second[0].WorkerID)
+	assert.Equal(t, "live-worker", *second[0].WorkerID)
+
+	require.NoError(t, jobRepo.ReleaseJob(ctx, nil, second[0].ID, "live-worker"))
+	third := claimAll(t, jobRepo, "another-worker", time.Minute, orderIDs)
+	require.Len(t, third, 1)
+}
+
+func TestExtendLease_KeepsJobFromOtherWorkers(t *testing.T) {
+	db := newIntegrationDB(t)
+	ctx := context.Background()
+	jobRepo := NewJobRepository(db.Pool)
+	orderIDs := seedJobs(t, db, 1)
+
+	claimed := claimAll(t, jobRepo, "slow-worker", 50*time.Millisecond, orderIDs)
+	require.Len(t, claimed, 1)
+
+	extended, err := jobRepo.ExtendLease(ctx, claimed[0].ID, "slow-worker", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, extended)
+	time.Sleep(100 * time.Millisecond)
+	assert.Empty(t, claimAll(t, jobRepo, "other-worker", time.Minute, orderIDs))
+
+	extended, err = jobRepo.ExtendLease(ctx, claimed[0].ID, "other-worker", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, extended, "lease held by another worker is not extended")
+}
+
+// claimAll разбирает всю очередь и возвращает только задания текущего теста.
+func claimAll(
+	t *testing.T,
+	jobRepo JobRepositoryInterface,
+	workerID string,
+	lease time.Duration,
+	orderIDs map[int64]struct{},
+) []entities.Job {
+	t.Helper()
+	var own []entities.Job
+	for {
+		batch, err := jobRepo.ClaimJobs(context.Background(), workerID, 100, lease)
+		require.NoError(t, err)
+		if len(batch) == 0 {
+			return own
+		}
+		for _, job := range batch {
+			if _, ok := orderIDs[job.OrderID]; ok {
+				own = append(own, job)
+			}
+		}
+	}
+}
+
+func TestClaimJobs_SkipsDelayedAndDeadJobs(t *testing.T) {
+	db := newIntegrationDB(t)
+	ctx := context.Background()
+	jobRepo := NewJobRepository(db.Pool)
+	orderIDs := seedJobs(t, db, 2)
+
+	claimed := claimAll(t, jobRepo, "worker", time.Minute, orderIDs)
+	require.Len(t, claimed, 2)
+
+	soon := time.Now().Add(200 * time.Millisecond)
+	noContent := "no content (204)"
+	noContentCategory := "no_content"
+	delayed := claimed[0]
+	delayed.Attempts, delayed.NextAttemptAt = 1, &soon
+	delayed.LastError, delayed.LastErrorCategory = &noContent, &noContentCategory
+	require.NoError(t, jobRepo.ScheduleRetry(ctx, nil, &delayed, "worker"))
+	dead := claimed[1]
+	dead.Attempts, dead.LastError, dead.LastErrorCategory = 10, &noContent, &noContentCategory
+	require.NoError(t, jobRepo.MarkJobDead(ctx, nil, &dead, "worker"))
+	assert.Empty(t, claimAll(t, jobRepo, "worker", time.Minute, orderIDs))
+
+	time.Sleep(300 * time.Millisecond)
+	retried := claimAll(t, jobRepo, "worker", time.Minute, orderIDs)
+	require.Len(t, retried, 1)
+	assert.Equal(t, claimed[0].ID, retried[0].ID)
+	assert.Equal(t, 1, retried[0].Attempts)
+	require.NotNil(t, retried[0].LastError)
+	assert.Equal(t, noContent, *retried[0].LastError)
+	require.NotNil(t, retried[0].LastErrorCategory)
+	assert.Equal(t, noContentCategory, *retried[0].LastErrorCategory)
+}
+
+func TestScheduleRetry_RequiresLease(t *testing.T) {
+	db := newIntegrationDB(t)
+	ctx := context.Background()
+	jobRepo := NewJobRepository(db.Pool)
+	orderIDs := seedJobs(t, db, 1)
+
+	// аренда первого воркера истекла, задание перехватил второй
+	claimAll(t, jobRepo, "stale-worker", 50*time.Millisecond, orderIDs)
+	time.Sleep(100 * time.Millisecond)
+	claimed := claimAll(t, jobRepo, "live-worker", time.Minute, orderIDs)
+	require.Len(t, claimed, 1)
+
+	later := time.Now().Add(time.Hour)
+	lastError, category := "internal server error (500)", "server_error"
+	failed := claimed[0]
+	failed.Attempts, failed.NextAttemptAt = 10, &later
+	failed.LastError, failed.LastErrorCategory = &lastError, &category
+	assert.ErrorIs(t, jobRepo.ScheduleRetry(ctx, nil, &failed, "stale-worker"), apperrors.ErrJobLeaseLost)
+	assert.ErrorIs(t, jobRepo.MarkJobDead(ctx, nil, &failed, "stale-worker"), apperrors.ErrJobLeaseLost)
+	assert.ErrorIs(t, jobRepo.ScheduleNextPoll(ctx, nil, &failed, "stale-worker"), apperrors.ErrJobLeaseLost)
+
+	// задание по-прежнему за вторым воркером
+	extended, err := jobRepo.ExtendLease(ctx, claimed[0].ID, "live-worker", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, extended)
+	require.NoError(t, jobRepo.MarkJobDead(ctx, nil, &failed, "live-worker"))
+}
+
+func TestEnqueueReverification_EnqueuesProcessedOrdersOnce(t *testing.T) {
+	db := newIntegrationDB(t)
+	ctx := context.Background()
+	userRepo := NewUserRepository(db.Pool)
+	orderRepo := NewOrderRepository(db.Pool)
+	jobRepo := NewJobRepository(db.Pool)
+
+	seed := time.Now().UnixNano()
+	user, err := userRepo.Store(ctx, entities.User{
+		Login:    fmt.Sprintf("job-reverify-%d", seed),
+		Password: "password",
+	})
+	require.NoError(t, err)
+	statuses := []int16{entities.StatusProcessed, entities.StatusProcessed, entities.StatusNew, entities.StatusInvalid}
+	orderIDs := make(map[int64]struct{}, len(statuses))
+	for i, status := range statuses {
+		order := &entities.Order{OrderID: int(seed%1_000_000_000) + i, UserID: int64(user.ID), StatusID: status}
+		id, err := orderRepo.Store(ctx, nil, order)
+		require.NoError(t, err)
+		orderIDs[int64(id)] = struct{}{}
+	}
+
+	filter := entities.ReverificationFilter{UserIDs: []int64{int64(user.ID)}}
+	result, err := jobRepo.EnqueueReverification(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, entities.ReverificationResult{Enqueued: 2}, result)
+
+	// задания уже стоят в очереди, повторный запрос их не дублирует
+	result, err = jobRepo.EnqueueReverification(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, entities.ReverificationResult{Skipped: 2}, result)
+
+	// задание из dead-letter возвращается в очередь с чистым счётчиком попыток
+	claimed := claimAll(t, jobRepo, "worker", time.Minute, orderIDs)
+	require.Len(t, claimed, 2)
+	assert.True(t, claimed[0].Reverify)
+	lastError, category := "internal server error (500)", "server_error"
+	dead := claimed[0]
+	dead.Attempts, dead.LastError, dead.LastErrorCategory = 10, &lastError, &category
+	require.NoError(t, jobRepo.MarkJobDead(ctx, nil, &dead, "worker"))
+	result, err = jobRepo.EnqueueReverification(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, entities.ReverificationResult{Revived: 1, Skipped: 1}, result)
+
+	revived := claimAll(t, jobRepo, "other-worker", time.Minute, map[int64]struct{}{dead.OrderID: {}})
+	require.Len(t, revived, 1)
+	assert.Equal(t, dead.ID, revived[0].ID)
+	assert.Zero(t, revived[0].Attempts)
+	assert.True(t, revived[0].Reverify)
+
+	from := time.Now().Add(time.Hour)
+	to := from.Add(time.Hour)
+	filter = entities.ReverificationFilter{From: &from, To: &to, UserIDs: []int64{int64(user.ID)}}
+	result, err = jobRepo.EnqueueReverification(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, entities.ReverificationResult{}, result)
+}
+
+func TestCountJobs_SplitsPendingAndDead(t *testing.T) {
+	db := newIntegrationDB(t)
+	ctx := context.Background()
+	jobRepo := NewJobRepository(db.Pool)
+	pendingBefore, deadBefore, err := jobRepo.CountJobs(ctx)
+	require.NoError(t, err)
+
+	orderIDs := seedJobs(t, db, 3)
+	claimed := claimAll(t, jobRepo, "worker", time.Minute, orderIDs)
+	require.Len(t, claimed, 3)
+	lastError, category := "internal server error (500)", "server_error"
+	dead := claimed[0]
+	dead.Attempts, dead.LastError, dead.LastErrorCategory = 10, &lastError, &category
+	require.NoError(t, jobRepo.MarkJobDead(ctx, nil, &dead, "worker"))
+
+	pending, deadCount, err := jobRepo.CountJobs(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, pendingBefore+2, pending)
+	assert.Equal(t, deadBefore+1, deadCount)
+}
+
+func TestClaimJobs_ReturnsTraceParent(t *testing.T) {
+	db := newIntegrationDB(t)
+	ctx := context.Background()
+	userRepo := NewUserRepository(db.Pool)
+	orderRepo := NewOrderRepository(db.Pool)
+	jobRepo := NewJobRepository(db.Pool)
+
+	seed := time.Now().UnixNano()
+	user, err := userRepo.Store(ctx, entities.User{
+		Login:    fmt.Sprintf("job-trace-%d", seed),
+		Password: "password",
+	})
+	require.NoError(t, err)
+	order := &entities.Order{OrderID: int(seed % 1_000_000_000), UserID: int64(user.ID), StatusID: entities.StatusNew}
+	id, err := orderRepo.Store(ctx, nil, order)
+	require.NoError(t, err)
+	traceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	require.NoError(t, jobRepo.SaveJob(ctx, nil, &entities.Job{OrderID: int64(id), TraceParent: &traceParent}))
+
+	claimed := claimAll(t, jobRepo, "worker", time.Minute, map[int64]struct{}{int64(id): {}})
+
+	require.Len(t, claimed, 1)
+	require.NotNil(t, claimed[0].TraceParent)
+	assert.Equal(t, traceParent, *claimed[0].TraceParent)
+}
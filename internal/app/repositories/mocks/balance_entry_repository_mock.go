@@ -0,0 +1,82 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/app/repositories/balance_entry_repository.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	entities "gophermart/internal/app/entities"
+	money "gophermart/internal/app/money"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	pgx "github.com/jackc/pgx/v5"
+)
+
+// MockBalanceEntryRepositoryInterface is a mock of BalanceEntryRepositoryInterface interface.
+type MockBalanceEntryRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockBalanceEntryRepositoryInterfaceMockRecorder
+}
+
+// MockBalanceEntryRepositoryInterfaceMockRecorder is the mock recorder for MockBalanceEntryRepositoryInterface.
+type MockBalanceEntryRepositoryInterfaceMockRecorder struct {
+	mock *MockBalanceEntryRepositoryInterface
+}
+
+// NewMockBalanceEntryRepositoryInterface creates a new mock instance.
+func NewMockBalanceEntryRepositoryInterface(ctrl *gomock.Controller) *MockBalanceEntryRepositoryInterface {
+	mock := &MockBalanceEntryRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockBalanceEntryRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBalanceEntryRepositoryInterface) EXPECT() *MockBalanceEntryRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// GetMismatches mocks base method.
+func (m *MockBalanceEntryRepositoryInterface) GetMismatches(ctx context.Context) ([]entities.BalanceMismatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMismatches", ctx)
+	ret0, _ := ret[0].([]entities.BalanceMismatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMismatches indicates an expected call of GetMismatches.
+func (mr *MockBalanceEntryRepositoryInterfaceMockRecorder) GetMismatches(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMismatches", reflect.TypeOf((*MockBalanceEntryRepositoryInterface)(nil).GetMismatches), ctx)
+}
+
+// GetWithdrawnByUserID mocks base method.
+func (m *MockBalanceEntryRepositoryInterface) GetWithdrawnByUserID(ctx context.Context, userID int) (money.Money, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWithdrawnByUserID", ctx, userID)
+	ret0, _ := ret[0].(money.Money)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWithdrawnByUserID indicates an expected call of GetWithdrawnByUserID.
+func (mr *MockBalanceEntryRepositoryInterfaceMockRecorder) GetWithdrawnByUserID(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithdrawnByUserID", reflect.TypeOf((*MockBalanceEntryRepositoryInterface)(nil).GetWithdrawnByUserID), ctx, userID)
+}
+
+// Store mocks base method.
+func (m *MockBalanceEntryRepositoryInterface) Store(ctx context.Context, tx pgx.Tx, entry *entities.BalanceEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Store", ctx, tx, entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Store indicates an expected call of Store.
+func (mr *MockBalanceEntryRepositoryInterfaceMockRecorder) Store(ctx, tx, entry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Store", reflect.TypeOf((*MockBalanceEntryRepositoryInterface)(nil).Store), ctx, tx, entry)
+}
@@ -0,0 +1,95 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/app/repositories/idempotency_repository.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	entities "gophermart/internal/app/entities"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIdempotencyRepositoryInterface is a mock of IdempotencyRepositoryInterface interface.
+type MockIdempotencyRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdempotencyRepositoryInterfaceMockRecorder
+}
+
+// MockIdempotencyRepositoryInterfaceMockRecorder is the mock recorder for MockIdempotencyRepositoryInterface.
+type MockIdempotencyRepositoryInterfaceMockRecorder struct {
+	mock *MockIdempotencyRepositoryInterface
+}
+
+// NewMockIdempotencyRepositoryInterface creates a new mock instance.
+func NewMockIdempotencyRepositoryInterface(ctrl *gomock.Controller) *MockIdempotencyRepositoryInterface {
+	mock := &MockIdempotencyRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockIdempotencyRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdempotencyRepositoryInterface) EXPECT() *MockIdempotencyRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Claim mocks base method.
+func (m *MockIdempotencyRepositoryInterface) Claim(ctx context.Context, key *entities.IdempotencyKey, ttl time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Claim", ctx, key, ttl)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Claim indicates an expected call of Claim.
+func (mr *MockIdempotencyRepositoryInterfaceMockRecorder) Claim(ctx, key, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Claim", reflect.TypeOf((*MockIdempotencyRepositoryInterface)(nil).Claim), ctx, key, ttl)
+}
+
+// Delete mocks base method.
+func (m *MockIdempotencyRepositoryInterface) Delete(ctx context.Context, userID int64, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, userID, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockIdempotencyRepositoryInterfaceMockRecorder) Delete(ctx, userID, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockIdempotencyRepositoryInterface)(nil).Delete), ctx, userID, key)
+}
+
+// GetByKey mocks base method.
+func (m *MockIdempotencyRepositoryInterface) GetByKey(ctx context.Context, userID int64, key string) (*entities.IdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByKey", ctx, userID, key)
+	ret0, _ := ret[0].(*entities.IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByKey indicates an expected call of GetByKey.
+func (mr *MockIdempotencyRepositoryInterfaceMockRecorder) GetByKey(ctx, userID, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByKey", reflect.TypeOf((*MockIdempotencyRepositoryInterface)(nil).GetByKey), ctx, userID, key)
+}
+
+// SaveResponse mocks base method.
+func (m *MockIdempotencyRepositoryInterface) SaveResponse(ctx context.Context, key *entities.IdempotencyKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveResponse", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveResponse indicates an expected call of SaveResponse.
+func (mr *MockIdempotencyRepositoryInterfaceMockRecorder) SaveResponse(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveResponse", reflect.TypeOf((*MockIdempotencyRepositoryInterface)(nil).SaveResponse), ctx, key)
+}
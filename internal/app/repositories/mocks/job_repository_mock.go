@@ -8,6 +8,7 @@ import (
 	context "context"
 	entities "gophermart/internal/app/entities"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	pgx "github.com/jackc/pgx/v5"
@@ -36,6 +37,37 @@ func (m *MockJobRepositoryInterface) EXPECT() *MockJobRepositoryInterfaceMockRec
 	return m.recorder
 }
 
+// ClaimJobs mocks base method.
+func (m *MockJobRepositoryInterface) ClaimJobs(ctx context.Context, workerID string, limit int, lease time.Duration) ([]entities.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimJobs", ctx, workerID, limit, lease)
+	ret0, _ := ret[0].([]entities.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimJobs indicates an expected call of ClaimJobs.
+func (mr *MockJobRepositoryInterfaceMockRecorder) ClaimJobs(ctx, workerID, limit, lease interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimJobs", reflect.TypeOf((*MockJobRepositoryInterface)(nil).ClaimJobs), ctx, workerID, limit, lease)
+}
+
+// CountJobs mocks base method.
+func (m *MockJobRepositoryInterface) CountJobs(ctx context.Context) (int64, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountJobs", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CountJobs indicates an expected call of CountJobs.
+func (mr *MockJobRepositoryInterfaceMockRecorder) CountJobs(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountJobs", reflect.TypeOf((*MockJobRepositoryInterface)(nil).CountJobs), ctx)
+}
+
 // DeleteJobByID mocks base method.
 func (m *MockJobRepositoryInterface) DeleteJobByID(ctx context.Context, tx pgx.Tx, jobID int64) error {
 	m.ctrl.T.Helper()
@@ -50,19 +82,76 @@ func (mr *MockJobRepositoryInterfaceMockRecorder) DeleteJobByID(ctx, tx, jobID i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteJobByID", reflect.TypeOf((*MockJobRepositoryInterface)(nil).DeleteJobByID), ctx, tx, jobID)
 }
 
-// GetPendingJobs mocks base method.
-func (m *MockJobRepositoryInterface) GetPendingJobs(ctx context.Context, tx pgx.Tx, limit int) ([]entities.Job, error) {
+// DeleteJobByOrderID mocks base method.
+func (m *MockJobRepositoryInterface) DeleteJobByOrderID(ctx context.Context, tx pgx.Tx, orderID int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPendingJobs", ctx, tx, limit)
-	ret0, _ := ret[0].([]entities.Job)
+	ret := m.ctrl.Call(m, "DeleteJobByOrderID", ctx, tx, orderID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteJobByOrderID indicates an expected call of DeleteJobByOrderID.
+func (mr *MockJobRepositoryInterfaceMockRecorder) DeleteJobByOrderID(ctx, tx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteJobByOrderID", reflect.TypeOf((*MockJobRepositoryInterface)(nil).DeleteJobByOrderID), ctx, tx, orderID)
+}
+
+// EnqueueReverification mocks base method.
+func (m *MockJobRepositoryInterface) EnqueueReverification(ctx context.Context, filter entities.ReverificationFilter) (entities.ReverificationResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnqueueReverification", ctx, filter)
+	ret0, _ := ret[0].(entities.ReverificationResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnqueueReverification indicates an expected call of EnqueueReverification.
+func (mr *MockJobRepositoryInterfaceMockRecorder) EnqueueReverification(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnqueueReverification", reflect.TypeOf((*MockJobRepositoryInterface)(nil).EnqueueReverification), ctx, filter)
+}
+
+// ExtendLease mocks base method.
+func (m *MockJobRepositoryInterface) ExtendLease(ctx context.Context, jobID int64, workerID string, lease time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExtendLease", ctx, jobID, workerID, lease)
+	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetPendingJobs indicates an expected call of GetPendingJobs.
-func (mr *MockJobRepositoryInterfaceMockRecorder) GetPendingJobs(ctx, tx, limit interface{}) *gomock.Call {
+// ExtendLease indicates an expected call of ExtendLease.
+func (mr *MockJobRepositoryInterfaceMockRecorder) ExtendLease(ctx, jobID, workerID, lease interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExtendLease", reflect.TypeOf((*MockJobRepositoryInterface)(nil).ExtendLease), ctx, jobID, workerID, lease)
+}
+
+// MarkJobDead mocks base method.
+func (m *MockJobRepositoryInterface) MarkJobDead(ctx context.Context, tx pgx.Tx, job *entities.Job, workerID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkJobDead", ctx, tx, job, workerID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkJobDead indicates an expected call of MarkJobDead.
+func (mr *MockJobRepositoryInterfaceMockRecorder) MarkJobDead(ctx, tx, job, workerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkJobDead", reflect.TypeOf((*MockJobRepositoryInterface)(nil).MarkJobDead), ctx, tx, job, workerID)
+}
+
+// ReleaseJob mocks base method.
+func (m *MockJobRepositoryInterface) ReleaseJob(ctx context.Context, tx pgx.Tx, jobID int64, workerID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseJob", ctx, tx, jobID, workerID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseJob indicates an expected call of ReleaseJob.
+func (mr *MockJobRepositoryInterfaceMockRecorder) ReleaseJob(ctx, tx, jobID, workerID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingJobs", reflect.TypeOf((*MockJobRepositoryInterface)(nil).GetPendingJobs), ctx, tx, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseJob", reflect.TypeOf((*MockJobRepositoryInterface)(nil).ReleaseJob), ctx, tx, jobID, workerID)
 }
 
 // SaveJob mocks base method.
@@ -79,16 +168,30 @@ func (mr *MockJobRepositoryInterfaceMockRecorder) SaveJob(ctx, tx, job interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveJob", reflect.TypeOf((*MockJobRepositoryInterface)(nil).SaveJob), ctx, tx, job)
 }
 
-// UpdateJobPoolAt mocks base method.
-func (m *MockJobRepositoryInterface) UpdateJobPoolAt(ctx context.Context, tx pgx.Tx, jobID int64) error {
+// ScheduleNextPoll mocks base method.
+func (m *MockJobRepositoryInterface) ScheduleNextPoll(ctx context.Context, tx pgx.Tx, job *entities.Job, workerID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduleNextPoll", ctx, tx, job, workerID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ScheduleNextPoll indicates an expected call of ScheduleNextPoll.
+func (mr *MockJobRepositoryInterfaceMockRecorder) ScheduleNextPoll(ctx, tx, job, workerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleNextPoll", reflect.TypeOf((*MockJobRepositoryInterface)(nil).ScheduleNextPoll), ctx, tx, job, workerID)
+}
+
+// ScheduleRetry mocks base method.
+func (m *MockJobRepositoryInterface) ScheduleRetry(ctx context.Context, tx pgx.Tx, job *entities.Job, workerID string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateJobPoolAt", ctx, tx, jobID)
+	ret := m.ctrl.Call(m, "ScheduleRetry", ctx, tx, job, workerID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UpdateJobPoolAt indicates an expected call of UpdateJobPoolAt.
-func (mr *MockJobRepositoryInterfaceMockRecorder) UpdateJobPoolAt(ctx, tx, jobID interface{}) *gomock.Call {
+// ScheduleRetry indicates an expected call of ScheduleRetry.
+func (mr *MockJobRepositoryInterfaceMockRecorder) ScheduleRetry(ctx, tx, job, workerID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateJobPoolAt", reflect.TypeOf((*MockJobRepositoryInterface)(nil).UpdateJobPoolAt), ctx, tx, jobID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleRetry", reflect.TypeOf((*MockJobRepositoryInterface)(nil).ScheduleRetry), ctx, tx, job, workerID)
 }
@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/app/repositories/nonce_repository.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockNonceRepositoryInterface is a mock of NonceRepositoryInterface interface.
+type MockNonceRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockNonceRepositoryInterfaceMockRecorder
+}
+
+// MockNonceRepositoryInterfaceMockRecorder is the mock recorder for MockNonceRepositoryInterface.
+type MockNonceRepositoryInterfaceMockRecorder struct {
+	mock *MockNonceRepositoryInterface
+}
+
+// NewMockNonceRepositoryInterface creates a new mock instance.
+func NewMockNonceRepositoryInterface(ctrl *gomock.Controller) *MockNonceRepositoryInterface {
+	mock := &MockNonceRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockNonceRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNonceRepositoryInterface) EXPECT() *MockNonceRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Claim mocks base method.
+func (m *MockNonceRepositoryInterface) Claim(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Claim", ctx, nonce, ttl)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Claim indicates an expected call of Claim.
+func (mr *MockNonceRepositoryInterfaceMockRecorder) Claim(ctx, nonce, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Claim", reflect.TypeOf((*MockNonceRepositoryInterface)(nil).Claim), ctx, nonce, ttl)
+}
+
+// Release mocks base method.
+func (m *MockNonceRepositoryInterface) Release(ctx context.Context, nonce string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx, nonce)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockNonceRepositoryInterfaceMockRecorder) Release(ctx, nonce interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockNonceRepositoryInterface)(nil).Release), ctx, nonce)
+}
@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	entities "gophermart/internal/app/entities"
+	money "gophermart/internal/app/money"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -97,10 +98,10 @@ func (mr *MockOrderRepositoryInterfaceMockRecorder) GetFreshOrders(ctx, limit in
 }
 
 // GetTotalAccrualByUserID mocks base method.
-func (m *MockOrderRepositoryInterface) GetTotalAccrualByUserID(ctx context.Context, userID int) (float64, error) {
+func (m *MockOrderRepositoryInterface) GetTotalAccrualByUserID(ctx context.Context, userID int) (money.Money, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetTotalAccrualByUserID", ctx, userID)
-	ret0, _ := ret[0].(float64)
+	ret0, _ := ret[0].(money.Money)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -111,6 +112,21 @@ func (mr *MockOrderRepositoryInterfaceMockRecorder) GetTotalAccrualByUserID(ctx,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTotalAccrualByUserID", reflect.TypeOf((*MockOrderRepositoryInterface)(nil).GetTotalAccrualByUserID), ctx, userID)
 }
 
+// LockByID mocks base method.
+func (m *MockOrderRepositoryInterface) LockByID(ctx context.Context, tx pgx.Tx, orderID int64) (*entities.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockByID", ctx, tx, orderID)
+	ret0, _ := ret[0].(*entities.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockByID indicates an expected call of LockByID.
+func (mr *MockOrderRepositoryInterfaceMockRecorder) LockByID(ctx, tx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockByID", reflect.TypeOf((*MockOrderRepositoryInterface)(nil).LockByID), ctx, tx, orderID)
+}
+
 // Store mocks base method.
 func (m *MockOrderRepositoryInterface) Store(ctx context.Context, tx pgx.Tx, order *entities.Order) (int, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,123 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/app/repositories/session_repository.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	entities "gophermart/internal/app/entities"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	pgx "github.com/jackc/pgx/v5"
+)
+
+// MockSessionRepositoryInterface is a mock of SessionRepositoryInterface interface.
+type MockSessionRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockSessionRepositoryInterfaceMockRecorder
+}
+
+// MockSessionRepositoryInterfaceMockRecorder is the mock recorder for MockSessionRepositoryInterface.
+type MockSessionRepositoryInterfaceMockRecorder struct {
+	mock *MockSessionRepositoryInterface
+}
+
+// NewMockSessionRepositoryInterface creates a new mock instance.
+func NewMockSessionRepositoryInterface(ctrl *gomock.Controller) *MockSessionRepositoryInterface {
+	mock := &MockSessionRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockSessionRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSessionRepositoryInterface) EXPECT() *MockSessionRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CreateSession mocks base method.
+func (m *MockSessionRepositoryInterface) CreateSession(ctx context.Context, tx pgx.Tx, session *entities.Session) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", ctx, tx, session)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockSessionRepositoryInterfaceMockRecorder) CreateSession(ctx, tx, session interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockSessionRepositoryInterface)(nil).CreateSession), ctx, tx, session)
+}
+
+// IsActive mocks base method.
+func (m *MockSessionRepositoryInterface) IsActive(ctx context.Context, sessionID int64, userID int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsActive", ctx, sessionID, userID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsActive indicates an expected call of IsActive.
+func (mr *MockSessionRepositoryInterfaceMockRecorder) IsActive(ctx, sessionID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsActive", reflect.TypeOf((*MockSessionRepositoryInterface)(nil).IsActive), ctx, sessionID, userID)
+}
+
+// LockRefreshToken mocks base method.
+func (m *MockSessionRepositoryInterface) LockRefreshToken(ctx context.Context, tx pgx.Tx, hash string) (*entities.RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockRefreshToken", ctx, tx, hash)
+	ret0, _ := ret[0].(*entities.RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockRefreshToken indicates an expected call of LockRefreshToken.
+func (mr *MockSessionRepositoryInterfaceMockRecorder) LockRefreshToken(ctx, tx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockRefreshToken", reflect.TypeOf((*MockSessionRepositoryInterface)(nil).LockRefreshToken), ctx, tx, hash)
+}
+
+// MarkRefreshTokenUsed mocks base method.
+func (m *MockSessionRepositoryInterface) MarkRefreshTokenUsed(ctx context.Context, tx pgx.Tx, tokenID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRefreshTokenUsed", ctx, tx, tokenID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkRefreshTokenUsed indicates an expected call of MarkRefreshTokenUsed.
+func (mr *MockSessionRepositoryInterfaceMockRecorder) MarkRefreshTokenUsed(ctx, tx, tokenID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRefreshTokenUsed", reflect.TypeOf((*MockSessionRepositoryInterface)(nil).MarkRefreshTokenUsed), ctx, tx, tokenID)
+}
+
+// RevokeSession mocks base method.
+func (m *MockSessionRepositoryInterface) RevokeSession(ctx context.Context, tx pgx.Tx, sessionID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, tx, sessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockSessionRepositoryInterfaceMockRecorder) RevokeSession(ctx, tx, sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockSessionRepositoryInterface)(nil).RevokeSession), ctx, tx, sessionID)
+}
+
+// StoreRefreshToken mocks base method.
+func (m *MockSessionRepositoryInterface) StoreRefreshToken(ctx context.Context, tx pgx.Tx, token *entities.RefreshToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreRefreshToken", ctx, tx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreRefreshToken indicates an expected call of StoreRefreshToken.
+func (mr *MockSessionRepositoryInterfaceMockRecorder) StoreRefreshToken(ctx, tx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreRefreshToken", reflect.TypeOf((*MockSessionRepositoryInterface)(nil).StoreRefreshToken), ctx, tx, token)
+}
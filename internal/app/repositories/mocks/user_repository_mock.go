@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	entities "gophermart/internal/app/entities"
+	money "gophermart/internal/app/money"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -36,11 +37,25 @@ func (m *MockUserRepositoryInterface) EXPECT() *MockUserRepositoryInterfaceMockR
 	return m.recorder
 }
 
+// AddBalanceByUserID mocks base method.
+func (m *MockUserRepositoryInterface) AddBalanceByUserID(ctx context.Context, tx pgx.Tx, amount money.Money, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddBalanceByUserID", ctx, tx, amount, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddBalanceByUserID indicates an expected call of AddBalanceByUserID.
+func (mr *MockUserRepositoryInterfaceMockRecorder) AddBalanceByUserID(ctx, tx, amount, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddBalanceByUserID", reflect.TypeOf((*MockUserRepositoryInterface)(nil).AddBalanceByUserID), ctx, tx, amount, userID)
+}
+
 // GetBalanceByUserID mocks base method.
-func (m *MockUserRepositoryInterface) GetBalanceByUserID(ctx context.Context, tx pgx.Tx, userID int64) (float64, error) {
+func (m *MockUserRepositoryInterface) GetBalanceByUserID(ctx context.Context, tx pgx.Tx, userID int64) (money.Money, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetBalanceByUserID", ctx, tx, userID)
-	ret0, _ := ret[0].(float64)
+	ret0, _ := ret[0].(money.Money)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -80,6 +95,21 @@ func (mr *MockUserRepositoryInterfaceMockRecorder) IsExistByID(ctx, id interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsExistByID", reflect.TypeOf((*MockUserRepositoryInterface)(nil).IsExistByID), ctx, id)
 }
 
+// LockBalanceByUserID mocks base method.
+func (m *MockUserRepositoryInterface) LockBalanceByUserID(ctx context.Context, tx pgx.Tx, userID int64) (money.Money, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockBalanceByUserID", ctx, tx, userID)
+	ret0, _ := ret[0].(money.Money)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockBalanceByUserID indicates an expected call of LockBalanceByUserID.
+func (mr *MockUserRepositoryInterfaceMockRecorder) LockBalanceByUserID(ctx, tx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockBalanceByUserID", reflect.TypeOf((*MockUserRepositoryInterface)(nil).LockBalanceByUserID), ctx, tx, userID)
+}
+
 // Store mocks base method.
 func (m *MockUserRepositoryInterface) Store(ctx context.Context, user entities.User) (entities.User, error) {
 	m.ctrl.T.Helper()
@@ -94,17 +124,3 @@ func (mr *MockUserRepositoryInterfaceMockRecorder) Store(ctx, user interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Store", reflect.TypeOf((*MockUserRepositoryInterface)(nil).Store), ctx, user)
 }
-
-// UpdateBalanceByUserID mocks base method.
-func (m *MockUserRepositoryInterface) UpdateBalanceByUserID(ctx context.Context, tx pgx.Tx, balance float64, userID int64) error {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateBalanceByUserID", ctx, tx, balance, userID)
-	ret0, _ := ret[0].(error)
-	return ret0
-}
-
-// UpdateBalanceByUserID indicates an expected call of UpdateBalanceByUserID.
-func (mr *MockUserRepositoryInterfaceMockRecorder) UpdateBalanceByUserID(ctx, tx, balance, userID interface{}) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBalanceByUserID", reflect.TypeOf((*MockUserRepositoryInterface)(nil).UpdateBalanceByUserID), ctx, tx, balance, userID)
-}
@@ -51,27 +51,13 @@ func (mr *MockWithdrawRepositoryInterfaceMockRecorder) GetByUserID(ctx, userID i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUserID", reflect.TypeOf((*MockWithdrawRepositoryInterface)(nil).GetByUserID), ctx, userID)
 }
 
-// GetTotalWithdrawByUserID mocks base method.
-func (m *MockWithdrawRepositoryInterface) GetTotalWithdrawByUserID(ctx context.Context, userID int) (float64, error) {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTotalWithdrawByUserID", ctx, userID)
-	ret0, _ := ret[0].(float64)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
-}
-
-// GetTotalWithdrawByUserID indicates an expected call of GetTotalWithdrawByUserID.
-func (mr *MockWithdrawRepositoryInterfaceMockRecorder) GetTotalWithdrawByUserID(ctx, userID interface{}) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTotalWithdrawByUserID", reflect.TypeOf((*MockWithdrawRepositoryInterface)(nil).GetTotalWithdrawByUserID), ctx, userID)
-}
-
 // Save mocks base method.
-func (m *MockWithdrawRepositoryInterface) Save(ctx context.Context, tx pgx.Tx, withdraw entities.Withdraw) error {
+func (m *MockWithdrawRepositoryInterface) Save(ctx context.Context, tx pgx.Tx, withdraw entities.Withdraw) (int, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Save", ctx, tx, withdraw)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Save indicates an expected call of Save.
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type NonceRepositoryInterface interface {
+	Claim(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+	Release(ctx context.Context, nonce string) error
+}
+
+type nonceRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewNonceRepository(db *pgxpool.Pool) NonceRepositoryInterface {
+	return &nonceRepository{
+		Pool: db,
+	}
+}
+
+// Claim запоминает nonce уведомления и возвращает false, если он уже встречался за последние ttl.
+// Более старые уведомления отсекаются по времени подписи, поэтому их nonce можно переиспользовать.
+func (r *nonceRepository) Claim(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	query := `
+		INSERT INTO callback_nonces (nonce)
+		VALUES ($1)
+		ON CONFLICT (nonce) DO UPDATE
+		SET created_at = now()
+		WHERE callback_nonces.created_at < now() - make_interval(secs => $2)
+		RETURNING created_at
+	`
+	var createdAt time.Time
+	err := r.Pool.QueryRow(ctx, query, nonce, ttl.Seconds()).Scan(&createdAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to claim callback nonce: %w", err)
+	}
+	return true, nil
+}
+
+// Release забывает nonce, чтобы уведомление, не обработанное из-за ошибки сервера, можно было повторить.
+func (r *nonceRepository) Release(ctx context.Context, nonce string) error {
+	query := `
+		DELETE FROM callback_nonces
+		WHERE nonce = $1
+	`
+	_, err := r.Pool.Exec(ctx, query, nonce)
+	if err != nil {
+		return fmt.Errorf("failed to release callback nonce: %w", err)
+	}
+	return nil
+}
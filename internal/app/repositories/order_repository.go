@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"gophermart/internal/app/apperrors"
 	"gophermart/internal/app/entities"
+	"gophermart/internal/app/money"
 
 	"github.com/jackc/pgx/v5"
 
@@ -20,9 +21,10 @@ type OrderRepositoryInterface interface {
 	UpdateOrder(ctx context.Context, tx pgx.Tx, order *entities.Order) error
 	GetFreshOrders(ctx context.Context, limit int) ([]entities.Order, error)
 	GetByUserID(ctx context.Context, userID int) ([]entities.Order, error)
-	GetTotalAccrualByUserID(ctx context.Context, userID int) (float64, error)
+	GetTotalAccrualByUserID(ctx context.Context, userID int) (money.Money, error)
 	GetByOrderNumber(ctx context.Context, orderNumber int64) (*entities.Order, error)
 	GetByID(ctx context.Context, tx pgx.Tx, orderID int64) (*entities.Order, error)
+	LockByID(ctx context.Context, tx pgx.Tx, orderID int64) (*entities.Order, error)
 }
 
 type orderRepository struct {
@@ -72,13 +74,13 @@ func (r *orderRepository) GetByUserID(ctx context.Context, userID int) ([]entiti
 
 func (r *orderRepository) GetByOrderNumber(ctx context.Context, orderNumber int64) (*entities.Order, error) {
 	query := `
-		SELECT order_number, user_id, status_id
+		SELECT id, order_number, user_id, status_id
 		FROM orders
 		WHERE order_number = $1
 	`
 
 	var order entities.Order
-	err := r.Pool.QueryRow(ctx, query, orderNumber).Scan(&order.OrderID, &order.UserID, &order.StatusID)
+	err := r.Pool.QueryRow(ctx, query, orderNumber).Scan(&order.ID, &order.OrderID, &order.UserID, &order.StatusID)
 	if err != nil {
 		return nil, apperrors.ErrOrderNotFound
 	}
@@ -88,18 +90,27 @@ func (r *orderRepository) GetByOrderNumber(ctx context.Context, orderNumber int6
 
 func (r *orderRepository) GetByID(ctx context.Context, tx pgx.Tx, orderID int64) (*entities.Order, error) {
 	query := `
-		SELECT order_number, user_id, status_id
+		SELECT id, order_number, user_id, status_id, accrual, previous_accrual, accrual_corrected_at
 		FROM orders
 		WHERE id = $1
 	`
 
 	var order entities.Order
-	var err error
+	var row pgx.Row
 	if tx != nil {
-		err = tx.QueryRow(ctx, query, orderID).Scan(&order.OrderID, &order.UserID, &order.StatusID)
+		row = tx.QueryRow(ctx, query, orderID)
 	} else {
-		err = r.Pool.QueryRow(ctx, query, orderID).Scan(&order.OrderID, &order.UserID, &order.StatusID)
+		row = r.Pool.QueryRow(ctx, query, orderID)
 	}
+	err := row.Scan(
+		&order.ID,
+		&order.OrderID,
+		&order.UserID,
+		&order.StatusID,
+		&order.Accrual,
+		&order.PreviousAccrual,
+		&order.AccrualCorrectedAt,
+	)
 	if err != nil {
 		return nil, apperrors.ErrOrderNotFound
 	}
@@ -107,6 +118,35 @@ func (r *orderRepository) GetByID(ctx context.Context, tx pgx.Tx, orderID int64)
 	return &order, nil
 }
 
+// LockByID читает заказ с блокировкой строки до конца транзакции tx.
+func (r *orderRepository) LockByID(ctx context.Context, tx pgx.Tx, orderID int64) (*entities.Order, error) {
+	query := `
+		SELECT id, order_number, user_id, status_id, accrual, previous_accrual, accrual_corrected_at
+		FROM orders
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	var order entities.Order
+	err := tx.QueryRow(ctx, query, orderID).Scan(
+		&order.ID,
+		&order.OrderID,
+		&order.UserID,
+		&order.StatusID,
+		&order.Accrual,
+		&order.PreviousAccrual,
+		&order.AccrualCorrectedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apperrors.ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock order %d: %w", orderID, err)
+	}
+
+	return &order, nil
+}
+
 func (r *orderRepository) Store(ctx context.Context, tx pgx.Tx, order *entities.Order) (int, error) {
 	query := `
 		INSERT INTO orders (order_number, user_id, status_id)
@@ -135,11 +175,19 @@ func (r *orderRepository) Store(ctx context.Context, tx pgx.Tx, order *entities.
 func (r *orderRepository) UpdateOrder(ctx context.Context, tx pgx.Tx, order *entities.Order) error {
 	query := `
 		UPDATE orders
-		SET status_id = $1, accrual = $2, updated_at = NOW()
-		WHERE order_number = $3
+		SET status_id = $1, accrual = $2, previous_accrual = $3, accrual_corrected_at = $4, updated_at = NOW()
+		WHERE order_number = $5
 	`
 	var err error
-	_, err = tx.Exec(ctx, query, order.StatusID, order.Accrual, order.OrderID)
+	_, err = tx.Exec(
+		ctx,
+		query,
+		order.StatusID,
+		order.Accrual,
+		order.PreviousAccrual,
+		order.AccrualCorrectedAt,
+		order.OrderID,
+	)
 	if err != nil {
 		return fmt.Errorf("failed to update order %d: %w", order.OrderID, err)
 	}
@@ -188,14 +236,14 @@ func (r *orderRepository) GetFreshOrders(ctx context.Context, limit int) ([]enti
 	return orders, nil
 }
 
-func (r *orderRepository) GetTotalAccrualByUserID(ctx context.Context, userID int) (float64, error) {
+func (r *orderRepository) GetTotalAccrualByUserID(ctx context.Context, userID int) (money.Money, error) {
 	query := `
 		SELECT COALESCE(SUM(accrual), 0)
 		FROM orders
 		WHERE user_id = $1
 	`
 
-	var totalAccrual float64
+	var totalAccrual money.Money
 	err := r.Pool.QueryRow(ctx, query, userID).Scan(&totalAccrual)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get total accrual for user %d: %w", userID, err)
@@ -0,0 +1,162 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gophermart/internal/app/apperrors"
+	"gophermart/internal/app/entities"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SessionRepositoryInterface interface {
+	CreateSession(ctx context.Context, tx pgx.Tx, session *entities.Session) error
+	RevokeSession(ctx context.Context, tx pgx.Tx, sessionID int64) error
+	IsActive(ctx context.Context, sessionID int64, userID int) (bool, error)
+	StoreRefreshToken(ctx context.Context, tx pgx.Tx, token *entities.RefreshToken) error
+	LockRefreshToken(ctx context.Context, tx pgx.Tx, hash string) (*entities.RefreshToken, error)
+	MarkRefreshTokenUsed(ctx context.Context, tx pgx.Tx, tokenID int64) error
+}
+
+type sessionRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewSessionRepository(db *pgxpool.Pool) SessionRepositoryInterface {
+	return &sessionRepository{
+		Pool: db,
+	}
+}
+
+func (r *sessionRepository) CreateSession(ctx context.Context, tx pgx.Tx, session *entities.Session) error {
+	query := `
+		INSERT INTO sessions (user_id)
+		VALUES ($1)
+		RETURNING id, created_at
+	`
+
+	var err error
+	if tx != nil {
+		err = tx.QueryRow(ctx, query, session.UserID).Scan(&session.ID, &session.CreatedAt)
+	} else {
+		err = r.Pool.QueryRow(ctx, query, session.UserID).Scan(&session.ID, &session.CreatedAt)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create session for user %d: %w", session.UserID, err)
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) RevokeSession(ctx context.Context, tx pgx.Tx, sessionID int64) error {
+	query := `
+		UPDATE sessions
+		SET revoked_at = now()
+		WHERE id = $1 AND revoked_at IS NULL
+	`
+
+	var err error
+	if tx != nil {
+		_, err = tx.Exec(ctx, query, sessionID)
+	} else {
+		_, err = r.Pool.Exec(ctx, query, sessionID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to revoke session %d: %w", sessionID, err)
+	}
+
+	return nil
+}
+
+// IsActive проверяет, что сессия принадлежит пользователю и не отозвана.
+func (r *sessionRepository) IsActive(ctx context.Context, sessionID int64, userID int) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM sessions
+			WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+		)
+	`
+
+	var active bool
+	err := r.Pool.QueryRow(ctx, query, sessionID, userID).Scan(&active)
+	if err != nil {
+		return false, fmt.Errorf("failed to check session %d: %w", sessionID, err)
+	}
+
+	return active, nil
+}
+
+func (r *sessionRepository) StoreRefreshToken(ctx context.Context, tx pgx.Tx, token *entities.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (session_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+
+	var err error
+	if tx != nil {
+		err = tx.QueryRow(ctx, query, token.SessionID, token.Hash, token.ExpiresAt).Scan(&token.ID, &token.CreatedAt)
+	} else {
+		err = r.Pool.QueryRow(ctx, query, token.SessionID, token.Hash, token.ExpiresAt).Scan(&token.ID, &token.CreatedAt)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store refresh token for session %d: %w", token.SessionID, err)
+	}
+
+	return nil
+}
+
+// LockRefreshToken читает токен по хэшу вместе с сессией и блокирует его строку до конца транзакции tx,
+// чтобы два параллельных обновления одним токеном не выдали две пары.
+func (r *sessionRepository) LockRefreshToken(
+	ctx context.Context,
+	tx pgx.Tx,
+	hash string,
+) (*entities.RefreshToken, error) {
+	query := `
+		SELECT t.id, t.session_id, s.user_id, t.token_hash, t.created_at, t.expires_at, t.used_at, s.revoked_at
+		FROM refresh_tokens t
+		JOIN sessions s ON s.id = t.session_id
+		WHERE t.token_hash = $1
+		FOR UPDATE OF t
+	`
+
+	var token entities.RefreshToken
+	err := tx.QueryRow(ctx, query, hash).Scan(
+		&token.ID,
+		&token.SessionID,
+		&token.UserID,
+		&token.Hash,
+		&token.CreatedAt,
+		&token.ExpiresAt,
+		&token.UsedAt,
+		&token.SessionRevokedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apperrors.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock refresh token: %w", err)
+	}
+
+	return &token, nil
+}
+
+func (r *sessionRepository) MarkRefreshTokenUsed(ctx context.Context, tx pgx.Tx, tokenID int64) error {
+	query := `
+		UPDATE refresh_tokens
+		SET used_at = now()
+		WHERE id = $1
+	`
+
+	_, err := tx.Exec(ctx, query, tokenID)
+	if err != nil {
+		return fmt.Errorf("failed to mark refresh token %d used: %w", tokenID, err)
+	}
+
+	return nil
+}
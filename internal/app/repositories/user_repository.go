@@ -2,11 +2,11 @@ package repositories
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	"gophermart/internal/app/apperrors"
 	"gophermart/internal/app/entities"
+	"gophermart/internal/app/money"
 
 	"github.com/jackc/pgx/v5"
 
@@ -20,8 +20,9 @@ type UserRepositoryInterface interface {
 	GetByLogin(ctx context.Context, login string) (entities.User, error)
 	Store(ctx context.Context, user entities.User) (entities.User, error)
 	IsExistByID(ctx context.Context, id int) bool
-	GetBalanceByUserID(ctx context.Context, tx pgx.Tx, userID int64) (float64, error)
-	UpdateBalanceByUserID(ctx context.Context, tx pgx.Tx, balance float64, userID int64) error
+	GetBalanceByUserID(ctx context.Context, tx pgx.Tx, userID int64) (money.Money, error)
+	LockBalanceByUserID(ctx context.Context, tx pgx.Tx, userID int64) (money.Money, error)
+	AddBalanceByUserID(ctx context.Context, tx pgx.Tx, amount money.Money, userID int64) error
 }
 
 type userRepository struct {
@@ -82,43 +83,56 @@ func (r *userRepository) IsExistByID(ctx context.Context, id int) bool {
 	return exists
 }
 
-func (r *userRepository) GetBalanceByUserID(ctx context.Context, tx pgx.Tx, userID int64) (float64, error) {
+func (r *userRepository) GetBalanceByUserID(ctx context.Context, tx pgx.Tx, userID int64) (money.Money, error) {
 	query := `
-		SELECT COALESCE(balance, 0)
+		SELECT balance
 		FROM users
 		WHERE id = $1
 	`
 
-	var totalAccrual sql.NullFloat64
+	var balance money.Money
 	var err error
 	if tx != nil {
-		err = tx.QueryRow(ctx, query, userID).Scan(&totalAccrual)
+		err = tx.QueryRow(ctx, query, userID).Scan(&balance)
 	} else {
-		err = r.Pool.QueryRow(ctx, query, userID).Scan(&totalAccrual)
+		err = r.Pool.QueryRow(ctx, query, userID).Scan(&balance)
 	}
 
 	if err != nil {
-		return 0, fmt.Errorf("failed to get total accrual for user %d: %w", userID, err)
+		return 0, fmt.Errorf("failed to get balance for user %d: %w", userID, err)
 	}
 
-	if !totalAccrual.Valid {
-		return 0, nil
+	return balance, nil
+}
+
+func (r *userRepository) LockBalanceByUserID(ctx context.Context, tx pgx.Tx, userID int64) (money.Money, error) {
+	query := `
+		SELECT balance
+		FROM users
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	var balance money.Money
+	err := tx.QueryRow(ctx, query, userID).Scan(&balance)
+	if err != nil {
+		return 0, fmt.Errorf("failed to lock balance for user %d: %w", userID, err)
 	}
 
-	return totalAccrual.Float64, nil
+	return balance, nil
 }
 
-func (r *userRepository) UpdateBalanceByUserID(ctx context.Context, tx pgx.Tx, newBalance float64, userID int64) error {
+func (r *userRepository) AddBalanceByUserID(ctx context.Context, tx pgx.Tx, amount money.Money, userID int64) error {
 	queryUser := `
 			UPDATE users
-			SET balance = $1
+			SET balance = balance + $1
 			WHERE id = $2
 		`
 	var err error
 	if tx != nil {
-		_, err = tx.Exec(ctx, queryUser, newBalance, userID)
+		_, err = tx.Exec(ctx, queryUser, amount, userID)
 	} else {
-		_, err = r.Pool.Exec(ctx, queryUser, newBalance, userID)
+		_, err = r.Pool.Exec(ctx, queryUser, amount, userID)
 	}
 
 	if err != nil {
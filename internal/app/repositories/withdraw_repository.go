@@ -16,9 +16,8 @@ import (
 )
 
 type WithdrawRepositoryInterface interface {
-	GetTotalWithdrawByUserID(ctx context.Context, userID int) (float64, error)
 	GetByUserID(ctx context.Context, userID int) ([]entities.Withdraw, error)
-	Save(ctx context.Context, tx pgx.Tx, withdraw entities.Withdraw) error
+	Save(ctx context.Context, tx pgx.Tx, withdraw entities.Withdraw) (int, error)
 }
 
 type withdrawRepository struct {
@@ -31,36 +30,21 @@ func NewWithdrawRepository(db *pgxpool.Pool) WithdrawRepositoryInterface {
 	}
 }
 
-func (r *withdrawRepository) Save(ctx context.Context, tx pgx.Tx, withdraw entities.Withdraw) error {
+func (r *withdrawRepository) Save(ctx context.Context, tx pgx.Tx, withdraw entities.Withdraw) (int, error) {
 	query := `
 		INSERT INTO withdraws (order_number, user_id, withdraw)
 		VALUES ($1, $2, $3)
+		RETURNING id
 	`
-	_, err := tx.Exec(ctx, query, withdraw.OrderID, withdraw.UserID, withdraw.Withdraw)
+	err := tx.QueryRow(ctx, query, withdraw.OrderID, withdraw.UserID, withdraw.Withdraw).Scan(&withdraw.ID)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgerrcode.IsIntegrityConstraintViolation(pgErr.Code) {
 			err = apperrors.ErrDuplicateOrderID
 		}
-		return fmt.Errorf("failed to save withdraw: %w", err)
+		return 0, fmt.Errorf("failed to save withdraw: %w", err)
 	}
-	return nil
-}
-
-func (r *withdrawRepository) GetTotalWithdrawByUserID(ctx context.Context, userID int) (float64, error) {
-	query := `
-		SELECT COALESCE(SUM(withdraw), 0)
-		FROM withdraws
-		WHERE user_id = $1
-	`
-
-	var totalAccrual float64
-	err := r.Pool.QueryRow(ctx, query, userID).Scan(&totalAccrual)
-	if err != nil {
-		return 0, fmt.Errorf("failed to get total accrual for user %d: %w", userID, err)
-	}
-
-	return totalAccrual, nil
+	return withdraw.ID, nil
 }
 
 func (r *withdrawRepository) GetByUserID(ctx context.Context, userID int) ([]entities.Withdraw, error) {
@@ -4,11 +4,19 @@ import (
 	"time"
 
 	"github.com/go-resty/resty/v2"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
-func NewClient(serverAddr string, timeout time.Duration) *resty.Client {
-	return resty.New().
+// NewClient создаёт клиент системы начислений; все запросы проходят через общий gate.
+// Транспорт открывает span на каждый запрос и передаёт traceparent системе начислений,
+// время каждого запроса попадает в gophermart_accrual_request_duration_seconds.
+func NewClient(serverAddr string, timeout time.Duration, gate *Gate) Client {
+	client := resty.New()
+	return NewRestyClient(withMetrics(client.
+		SetTransport(otelhttp.NewTransport(client.GetClient().Transport)).
 		SetBaseURL(serverAddr).
 		SetHeader("Content-Type", "application/json").
-		SetTimeout(timeout)
+		SetTimeout(timeout).
+		OnBeforeRequest(gate.beforeRequest).
+		OnAfterResponse(gate.afterResponse)))
 }
@@ -0,0 +1,185 @@
+package accrual
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BreakerState — состояние предохранителя перед системой начислений.
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // запросы проходят, считаются неудачи подряд
+	BreakerOpen                         // запросы не отправляются до истечения OpenTimeout
+	BreakerHalfOpen                     // пропускается HalfOpenRequests пробных запросов
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// CircuitOpenError возвращается без обращения к сети, пока предохранитель разомкнут.
+type CircuitOpenError struct {
+	Until time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("accrual circuit breaker is open until %s", e.Until.Format(time.RFC3339))
+}
+
+type BreakerConfig struct {
+	// FailureThreshold — число неудач подряд, после которого предохранитель размыкается.
+	FailureThreshold int
+	// OpenTimeout — время, в течение которого запросы не отправляются.
+	OpenTimeout time.Duration
+	// HalfOpenRequests — число успешных пробных запросов, после которых предохранитель замыкается.
+	HalfOpenRequests int
+}
+
+// Breaker — общий для всех воркеров предохранитель. Неудачей считаются только ответ 500 и сетевые ошибки:
+// 204, 429 и некорректный ответ означают, что система начислений доступна.
+type Breaker struct {
+	openUntil     time.Time
+	now           func() time.Time
+	onStateChange func(from, to BreakerState)
+	cfg           BreakerConfig
+	state         BreakerState
+	failures      int
+	probes        int
+	successes     int
+	generation    uint64
+	mu            sync.Mutex
+}
+
+func NewBreaker(cfg BreakerConfig) *Breaker {
+	return &Breaker{cfg: cfg, now: time.Now}
+}
+
+// OnStateChange задаёт обработчик смены состояния; вызывается под блокировкой, поэтому должен быть быстрым.
+func (b *Breaker) OnStateChange(fn func(from, to BreakerState)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onStateChange = fn
+}
+
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// allow решает, можно ли отправить запрос. Возвращает поколение состояния, в котором запрос был разрешён:
+// результаты запросов, начатых до смены состояния, не учитываются.
+func (b *Breaker) allow() (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if b.state == BreakerOpen {
+		if now.Before(b.openUntil) {
+			return 0, &CircuitOpenError{Until: b.openUntil}
+		}
+		b.setState(BreakerHalfOpen)
+	}
+	if b.state == BreakerHalfOpen {
+		if b.probes >= b.cfg.HalfOpenRequests {
+			// пробные запросы уже в пути, остальные ждут их результата
+			return 0, &CircuitOpenError{Until: now.Add(b.cfg.OpenTimeout)}
+		}
+		b.probes++
+	}
+	return b.generation, nil
+}
+
+func (b *Breaker) record(generation uint64, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if generation != b.generation {
+		return
+	}
+
+	switch b.state {
+	case BreakerClosed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.cfg.FailureThreshold {
+			b.open()
+		}
+	case BreakerHalfOpen:
+		b.probes--
+		if failed {
+			b.open()
+			return
+		}
+		b.successes++
+		if b.successes >= b.cfg.HalfOpenRequests {
+			b.setState(BreakerClosed)
+		}
+	}
+}
+
+// release освобождает пробный запрос, результат которого неизвестен, например из-за остановки воркера.
+func (b *Breaker) release(generation uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if generation == b.generation && b.state == BreakerHalfOpen {
+		b.probes--
+	}
+}
+
+func (b *Breaker) open() {
+	b.openUntil = b.now().Add(b.cfg.OpenTimeout)
+	b.setState(BreakerOpen)
+}
+
+func (b *Breaker) setState(state BreakerState) {
+	from := b.state
+	b.state = state
+	b.generation++
+	b.failures = 0
+	b.probes = 0
+	b.successes = 0
+	if b.onStateChange != nil && from != state {
+		b.onStateChange(from, state)
+	}
+}
+
+type breakerClient struct {
+	client  Client
+	breaker *Breaker
+}
+
+// WithBreaker не пропускает запросы к системе начислений, пока предохранитель разомкнут.
+func WithBreaker(client Client, breaker *Breaker) Client {
+	return &breakerClient{client: client, breaker: breaker}
+}
+
+func (c *breakerClient) GetOrder(ctx context.Context, number int) (*OrderResponse, error) {
+	generation, err := c.breaker.allow()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.GetOrder(ctx, number)
+	if ctx.Err() != nil {
+		// запрос прерван вызывающим, о доступности системы начислений он ничего не говорит
+		c.breaker.release(generation)
+		return resp, err
+	}
+	c.breaker.record(generation, errors.Is(err, ErrServerError) || errors.Is(err, ErrNetwork))
+	return resp, err
+}
@@ -0,0 +1,176 @@
+package accrual
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubClient отвечает заданной ошибкой и считает запросы, дошедшие до сети.
+type stubClient struct {
+	err   error
+	calls int
+}
+
+func (c *stubClient) GetOrder(context.Context, int) (*OrderResponse, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &OrderResponse{Status: "PROCESSED"}, nil
+}
+
+var testBreakerConfig = BreakerConfig{FailureThreshold: 3, OpenTimeout: time.Minute, HalfOpenRequests: 2}
+
+// getOrder запрашивает заказ через автомат, заставив заглушку ответить err.
+func getOrder(client Client, stub *stubClient, err error) error {
+	stub.err = err
+	_, err = client.GetOrder(context.Background(), 123)
+	return err
+}
+
+// trip открывает автомат, отправив подряд FailureThreshold ошибок.
+func trip(t *testing.T, client Client, stub *stubClient, breaker *Breaker) {
+	t.Helper()
+	for range testBreakerConfig.FailureThreshold {
+		require.ErrorIs(t, getOrder(client, stub, ErrServerError), ErrServerError)
+	}
+	require.Equal(t, BreakerOpen, breaker.State())
+}
+
+func TestBreaker_OpensAfterConsecutiveFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		errs     []error
+		expected BreakerState
+	}{
+		{
+			name:     "server errors open the circuit",
+			errs:     []error{ErrServerError, ErrServerError, ErrServerError},
+			expected: BreakerOpen,
+		},
+		{
+			name:     "network errors open the circuit",
+			errs:     []error{ErrNetwork, ErrServerError, ErrNetwork},
+			expected: BreakerOpen,
+		},
+		{
+			name:     "success resets the failure count",
+			errs:     []error{ErrServerError, ErrServerError, nil, ErrServerError, ErrServerError},
+			expected: BreakerClosed,
+		},
+		{
+			name:     "204 and 429 mean the system is up",
+			errs:     []error{ErrNoContent, &TooManyRequestsWithRetryError{RetryAfter: 1}, ErrNoContent},
+			expected: BreakerClosed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &stubClient{}
+			breaker := NewBreaker(testBreakerConfig)
+			client := WithBreaker(stub, breaker)
+			for _, err := range tt.errs {
+				_ = getOrder(client, stub, err)
+			}
+			assert.Equal(t, tt.expected, breaker.State())
+		})
+	}
+}
+
+func TestBreaker_OpenCircuitSkipsNetworkCall(t *testing.T) {
+	stub := &stubClient{}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	breaker := NewBreaker(testBreakerConfig)
+	breaker.now = func() time.Time { return now }
+	client := WithBreaker(stub, breaker)
+	trip(t, client, stub, breaker)
+
+	err := getOrder(client, stub, nil)
+
+	var openErr *CircuitOpenError
+	require.ErrorAs(t, err, &openErr)
+	assert.Equal(t, now.Add(time.Minute), openErr.Until)
+	assert.Equal(t, CategoryCircuitOpen, Categorize(err))
+	assert.Equal(t, 3, stub.calls)
+}
+
+func TestBreaker_HalfOpenClosesAfterSuccessfulProbes(t *testing.T) {
+	stub := &stubClient{}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	breaker := NewBreaker(testBreakerConfig)
+	breaker.now = func() time.Time { return now }
+	var changes []BreakerState
+	breaker.OnStateChange(func(_, to BreakerState) {
+		changes = append(changes, to)
+	})
+	client := WithBreaker(stub, breaker)
+	trip(t, client, stub, breaker)
+	now = now.Add(time.Minute)
+
+	require.NoError(t, getOrder(client, stub, nil))
+	assert.Equal(t, BreakerHalfOpen, breaker.State())
+	require.NoError(t, getOrder(client, stub, nil))
+
+	assert.Equal(t, BreakerClosed, breaker.State())
+	assert.Equal(t, []BreakerState{BreakerOpen, BreakerHalfOpen, BreakerClosed}, changes)
+}
+
+func TestBreaker_HalfOpenFailureReopens(t *testing.T) {
+	stub := &stubClient{}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	breaker := NewBreaker(testBreakerConfig)
+	breaker.now = func() time.Time { return now }
+	client := WithBreaker(stub, breaker)
+	trip(t, client, stub, breaker)
+	now = now.Add(time.Minute)
+
+	require.ErrorIs(t, getOrder(client, stub, ErrNetwork), ErrNetwork)
+
+	assert.Equal(t, BreakerOpen, breaker.State())
+	var openErr *CircuitOpenError
+	require.ErrorAs(t, getOrder(client, stub, nil), &openErr)
+	assert.Equal(t, now.Add(time.Minute), openErr.Until)
+}
+
+func TestBreaker_HalfOpenLimitsProbes(t *testing.T) {
+	stub := &stubClient{}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	breaker := NewBreaker(testBreakerConfig)
+	breaker.now = func() time.Time { return now }
+	client := WithBreaker(stub, breaker)
+	trip(t, client, stub, breaker)
+	now = now.Add(time.Minute)
+
+	first, err := breaker.allow()
+	require.NoError(t, err)
+	_, err = breaker.allow()
+	require.NoError(t, err)
+	_, err = breaker.allow()
+	var openErr *CircuitOpenError
+	require.ErrorAs(t, err, &openErr)
+
+	// прерванный пробный запрос освобождает место для следующего
+	breaker.release(first)
+	_, err = breaker.allow()
+	assert.NoError(t, err)
+}
+
+func TestBreaker_IgnoresCallerCancellation(t *testing.T) {
+	stub := &stubClient{}
+	breaker := NewBreaker(testBreakerConfig)
+	client := WithBreaker(stub, breaker)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	stub.err = errors.Join(ErrNetwork, context.Canceled)
+	for range 5 {
+		_, _ = client.GetOrder(ctx, 123)
+	}
+
+	assert.Equal(t, BreakerClosed, breaker.State())
+}
@@ -0,0 +1,32 @@
+package accrual
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// Заголовки подписанного уведомления системы начислений о смене статуса заказа.
+const (
+	CallbackTimestampHeader = "X-Accrual-Timestamp"
+	CallbackNonceHeader     = "X-Accrual-Nonce"
+	CallbackSignatureHeader = "X-Accrual-Signature"
+)
+
+// SignCallback считает HMAC-SHA256 от строки «timestamp.nonce.body» общим секретом и возвращает его в hex.
+func SignCallback(secret []byte, timestamp int64, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write([]byte(nonce))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyCallback сравнивает подпись за постоянное время.
+func VerifyCallback(secret []byte, timestamp int64, nonce string, body []byte, signature string) bool {
+	expected := SignCallback(secret, timestamp, nonce, body)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
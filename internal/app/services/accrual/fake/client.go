@@ -0,0 +1,133 @@
+// Package fake — сценарный клиент системы начислений для тестов без HTTP-сервера.
+package fake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gophermart/internal/app/money"
+	"gophermart/internal/app/services/accrual"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var errConnectionRefused = errors.New("connection refused")
+
+// Step — один ответ системы начислений. Нулевой StatusCode означает 200 с полями Status и Accrual.
+type Step struct {
+	Err        error
+	Accrual    *money.Money
+	Status     string
+	Delay      time.Duration
+	StatusCode int
+	RetryAfter int
+}
+
+func Status(status string) Step {
+	return Step{Status: status}
+}
+
+func Processed(accrual money.Money) Step {
+	return Step{Status: "PROCESSED", Accrual: &accrual}
+}
+
+func NoContent() Step {
+	return Step{StatusCode: http.StatusNoContent}
+}
+
+func TooManyRequests(retryAfter int) Step {
+	return Step{StatusCode: http.StatusTooManyRequests, RetryAfter: retryAfter}
+}
+
+func ServerError() Step {
+	return Step{StatusCode: http.StatusInternalServerError}
+}
+
+// NetworkError имитирует обрыв соединения: запрос не дошёл до системы начислений.
+func NetworkError() Step {
+	return Step{Err: errConnectionRefused}
+}
+
+// After добавляет к ответу задержку; отмена контекста прерывает ожидание.
+func (s Step) After(delay time.Duration) Step {
+	s.Delay = delay
+	return s
+}
+
+// Client отдаёт ответы по сценарию для каждого номера заказа; последний шаг повторяется.
+// Заказ без сценария считается незарегистрированным (204).
+type Client struct {
+	scripts map[int][]Step
+	calls   map[int]int
+	mu      sync.Mutex
+}
+
+func New() *Client {
+	return &Client{
+		scripts: make(map[int][]Step),
+		calls:   make(map[int]int),
+	}
+}
+
+func (c *Client) Script(number int, steps ...Step) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.scripts[number] = append(c.scripts[number], steps...)
+	return c
+}
+
+// Calls возвращает число запросов по заказу.
+func (c *Client) Calls(number int) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls[number]
+}
+
+func (c *Client) GetOrder(ctx context.Context, number int) (*accrual.OrderResponse, error) {
+	step := c.next(number)
+
+	if step.Delay > 0 {
+		timer := time.NewTimer(step.Delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("failed to send order: %w: %w", accrual.ErrNetwork, ctx.Err())
+		case <-timer.C:
+		}
+	}
+
+	if step.Err != nil {
+		return nil, fmt.Errorf("failed to send order: %w: %w", accrual.ErrNetwork, step.Err)
+	}
+	switch step.StatusCode {
+	case 0, http.StatusOK:
+		return &accrual.OrderResponse{
+			Order:   strconv.Itoa(number),
+			Status:  step.Status,
+			Accrual: step.Accrual,
+		}, nil
+	case http.StatusNoContent:
+		return nil, accrual.ErrNoContent
+	case http.StatusTooManyRequests:
+		return nil, &accrual.TooManyRequestsWithRetryError{RetryAfter: step.RetryAfter}
+	case http.StatusInternalServerError:
+		return nil, accrual.ErrServerError
+	default:
+		return nil, fmt.Errorf("unexpected status code: %d", step.StatusCode)
+	}
+}
+
+func (c *Client) next(number int) Step {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	call := c.calls[number]
+	c.calls[number]++
+
+	steps := c.scripts[number]
+	if len(steps) == 0 {
+		return NoContent()
+	}
+	return steps[min(call, len(steps)-1)]
+}
@@ -0,0 +1,63 @@
+package fake
+
+import (
+	"context"
+	"gophermart/internal/app/money"
+	"gophermart/internal/app/services/accrual"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_PlaysScriptInOrder(t *testing.T) {
+	ctx := context.Background()
+	client := New().Script(42,
+		Status("REGISTERED"),
+		TooManyRequests(3),
+		ServerError(),
+		NetworkError(),
+		Processed(money.FromMinorUnits(50000)),
+	)
+
+	response, err := client.GetOrder(ctx, 42)
+	require.NoError(t, err)
+	assert.Equal(t, "REGISTERED", response.Status)
+	assert.Equal(t, "42", response.Order)
+
+	_, err = client.GetOrder(ctx, 42)
+	var tooManyErr *accrual.TooManyRequestsWithRetryError
+	require.ErrorAs(t, err, &tooManyErr)
+	assert.Equal(t, 3, tooManyErr.RetryAfter)
+
+	_, err = client.GetOrder(ctx, 42)
+	assert.ErrorIs(t, err, accrual.ErrServerError)
+
+	_, err = client.GetOrder(ctx, 42)
+	assert.ErrorIs(t, err, accrual.ErrNetwork)
+
+	// последний шаг повторяется
+	for range 2 {
+		response, err = client.GetOrder(ctx, 42)
+		require.NoError(t, err)
+		assert.Equal(t, "PROCESSED", response.Status)
+		assert.Equal(t, money.FromMinorUnits(50000), *response.Accrual)
+	}
+	assert.Equal(t, 6, client.Calls(42))
+}
+
+func TestClient_UnscriptedOrderIsNotRegistered(t *testing.T) {
+	_, err := New().GetOrder(context.Background(), 7)
+	assert.ErrorIs(t, err, accrual.ErrNoContent)
+}
+
+func TestClient_DelayHonorsContext(t *testing.T) {
+	client := New().Script(1, Status("PROCESSING").After(time.Hour))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := client.GetOrder(ctx, 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, accrual.CategoryNetwork, accrual.Categorize(err))
+}
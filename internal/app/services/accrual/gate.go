@@ -0,0 +1,158 @@
+package accrual
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const defaultRetryAfter = 30 * time.Second
+
+var rateLimitPattern = regexp.MustCompile(`No more than (\d+) requests per minute`)
+
+// Gate — общий для всех воркеров ограничитель запросов к системе начислений.
+// Пропускает не больше requestsPerMinute запросов в минуту и держит всех после ответа 429 до истечения Retry-After.
+// Лимит из тела ответа 429 хранится отдельно от настроенного и может только ужесточить его.
+type Gate struct {
+	pausedUntil time.Time
+	next        time.Time
+	interval    time.Duration
+	configured  int
+	advertised  int
+	mu          sync.Mutex
+}
+
+// NewGate создаёт ограничитель; requestsPerMinute <= 0 снимает ограничение до первого ответа 429.
+func NewGate(requestsPerMinute int) *Gate {
+	g := &Gate{}
+	g.SetRate(requestsPerMinute)
+	return g
+}
+
+// Wait блокирует до момента, когда можно отправить следующий запрос, или до отмены ctx.
+func (g *Gate) Wait(ctx context.Context) error {
+	for {
+		g.mu.Lock()
+		now := time.Now()
+		wakeAt := g.pausedUntil
+		if g.next.After(wakeAt) {
+			wakeAt = g.next
+		}
+		if !now.Before(wakeAt) {
+			g.next = now.Add(g.interval)
+			g.mu.Unlock()
+			return nil
+		}
+		g.mu.Unlock()
+
+		// пауза могла продлиться, пока мы ждали, поэтому после таймера проверяем заново
+		timer := time.NewTimer(wakeAt.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("wait for accrual rate limit: %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// Pause запрещает запросы на время d; более ранний срок не сокращает уже назначенную паузу.
+func (g *Gate) Pause(d time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if until := time.Now().Add(d); until.After(g.pausedUntil) {
+		g.pausedUntil = until
+	}
+}
+
+func (g *Gate) PausedUntil() time.Time {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.pausedUntil
+}
+
+// SetRate задаёт лимит из конфигурации и сбрасывает лимит, объявленный системой начислений в ответе 429.
+func (g *Gate) SetRate(requestsPerMinute int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.configured = requestsPerMinute
+	g.advertised = 0
+	g.applyRate()
+}
+
+// tighten запоминает лимит из ответа 429; действует меньший из настроенного и объявленного.
+func (g *Gate) tighten(requestsPerMinute int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.advertised > 0 && g.advertised <= requestsPerMinute {
+		return
+	}
+	g.advertised = requestsPerMinute
+	g.applyRate()
+}
+
+func (g *Gate) applyRate() {
+	requestsPerMinute := g.configured
+	if g.advertised > 0 && (requestsPerMinute <= 0 || g.advertised < requestsPerMinute) {
+		requestsPerMinute = g.advertised
+	}
+	if requestsPerMinute <= 0 {
+		g.interval = 0
+		return
+	}
+	g.interval = time.Minute / time.Duration(requestsPerMinute)
+}
+
+func (g *Gate) beforeRequest(_ *resty.Client, r *resty.Request) error {
+	return g.Wait(r.Context())
+}
+
+func (g *Gate) afterResponse(_ *resty.Client, resp *resty.Response) error {
+	if resp.StatusCode() != http.StatusTooManyRequests {
+		return nil
+	}
+	g.Pause(parseRetryAfter(resp.Header().Get("Retry-After"), time.Now()))
+	if requestsPerMinute, ok := parseRateLimit(resp.Body()); ok {
+		g.tighten(requestsPerMinute)
+	}
+	return nil
+}
+
+// parseRetryAfter понимает оба формата заголовка: число секунд и HTTP-дату.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return defaultRetryAfter
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		if date.Before(now) {
+			return 0
+		}
+		return date.Sub(now)
+	}
+	return defaultRetryAfter
+}
+
+// parseRateLimit достаёт лимит из тела ответа 429: «No more than N requests per minute allowed».
+func parseRateLimit(body []byte) (int, bool) {
+	match := rateLimitPattern.FindSubmatch(body)
+	if match == nil {
+		return 0, false
+	}
+	requestsPerMinute, err := strconv.Atoi(string(match[1]))
+	if err != nil || requestsPerMinute <= 0 {
+		return 0, false
+	}
+	return requestsPerMinute, true
+}
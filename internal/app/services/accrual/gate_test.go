@@ -0,0 +1,167 @@
+package accrual
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAccrualServer отвечает 429 на первый запрос и проверяет, что до конца паузы больше никто не приходит.
+type fakeAccrualServer struct {
+	t           *testing.T
+	pausedUntil time.Time
+	arrivals    []time.Time
+	retryAfter  string
+	mu          sync.Mutex
+}
+
+func (s *fakeAccrualServer) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.arrivals = append(s.arrivals, now)
+	if len(s.arrivals) == 1 {
+		s.pausedUntil = now.Add(time.Second)
+		w.Header().Set("Retry-After", s.retryAfter)
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte("No more than 600 requests per minute allowed"))
+		return
+	}
+	if now.Before(s.pausedUntil) {
+		s.t.Errorf("request arrived %s before Retry-After deadline", s.pausedUntil.Sub(now))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"order": "123", "status": "PROCESSED", "accrual": 500}`))
+}
+
+func TestGate_RetryAfterPausesAllWorkers(t *testing.T) {
+	fake := &fakeAccrualServer{t: t, retryAfter: "1"}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	gate := NewGate(0)
+	client := NewClient(server.URL, time.Second, gate)
+
+	_, err := client.GetOrder(context.Background(), 123)
+	var tooManyErr *TooManyRequestsWithRetryError
+	require.ErrorAs(t, err, &tooManyErr)
+	assert.Equal(t, 1, tooManyErr.RetryAfter)
+
+	const workers = 5
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.GetOrder(context.Background(), 123)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	require.Len(t, fake.arrivals, workers+1)
+	// лимит из тела ответа 429 (600 в минуту) разносит запросы минимум на 100 мс
+	for i := 2; i < len(fake.arrivals); i++ {
+		assert.GreaterOrEqual(t, fake.arrivals[i].Sub(fake.arrivals[i-1]), 90*time.Millisecond)
+	}
+}
+
+func TestGate_HonorsRequestsPerMinute(t *testing.T) {
+	gate := NewGate(1200)
+
+	start := time.Now()
+	for range 4 {
+		require.NoError(t, gate.Wait(context.Background()))
+	}
+	// первый запрос проходит сразу, остальные три — через 50 мс каждый
+	assert.GreaterOrEqual(t, time.Since(start), 140*time.Millisecond)
+}
+
+func TestGate_AdvertisedRateOnlyTightens(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured int
+		advertised []int
+		expected   time.Duration
+	}{
+		{name: "stricter than configured", configured: 600, advertised: []int{60}, expected: time.Second},
+		{name: "looser than configured", configured: 60, advertised: []int{600}, expected: time.Second},
+		{name: "no configured limit", configured: 0, advertised: []int{120}, expected: 500 * time.Millisecond},
+		{name: "later looser limit ignored", configured: 0, advertised: []int{60, 600}, expected: time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gate := NewGate(tt.configured)
+			for _, requestsPerMinute := range tt.advertised {
+				gate.tighten(requestsPerMinute)
+			}
+
+			assert.Equal(t, tt.expected, gate.interval)
+		})
+	}
+}
+
+func TestGate_SetRateRestoresConfiguredRate(t *testing.T) {
+	gate := NewGate(600)
+	gate.tighten(60)
+	require.Equal(t, time.Second, gate.interval)
+
+	gate.SetRate(600)
+
+	assert.Equal(t, 100*time.Millisecond, gate.interval)
+}
+
+func TestGate_WaitStopsOnCancelledContext(t *testing.T) {
+	gate := NewGate(0)
+	gate.Pause(time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, gate.Wait(ctx), context.DeadlineExceeded)
+}
+
+func TestGate_PauseNeverShortens(t *testing.T) {
+	gate := NewGate(0)
+	gate.Pause(time.Hour)
+	deadline := gate.PausedUntil()
+	gate.Pause(time.Second)
+	assert.Equal(t, deadline, gate.PausedUntil())
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		header   string
+		expected time.Duration
+	}{
+		{name: "seconds", header: "60", expected: time.Minute},
+		{name: "http date", header: "Fri, 01 Mar 2024 12:00:30 GMT", expected: 30 * time.Second},
+		{name: "date in the past", header: "Fri, 01 Mar 2024 11:00:00 GMT", expected: 0},
+		{name: "missing", header: "", expected: defaultRetryAfter},
+		{name: "garbage", header: "soon", expected: defaultRetryAfter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseRetryAfter(tt.header, now))
+		})
+	}
+}
+
+func TestParseRateLimit(t *testing.T) {
+	requestsPerMinute, ok := parseRateLimit([]byte("No more than 60 requests per minute allowed"))
+	assert.True(t, ok)
+	assert.Equal(t, 60, requestsPerMinute)
+
+	_, ok = parseRateLimit([]byte("Too Many Requests"))
+	assert.False(t, ok)
+}
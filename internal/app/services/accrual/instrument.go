@@ -0,0 +1,51 @@
+package accrual
+
+import (
+	"errors"
+	"gophermart/internal/metrics"
+	"net/http"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// withMetrics замеряет время HTTP-запросов к системе начислений по исходу. resty отсчитывает время
+// после хуков OnBeforeRequest, поэтому ожидание gate и пауза после 429 в метрику не попадают,
+// как и запросы, отсечённые предохранителем до отправки.
+func withMetrics(client *resty.Client) *resty.Client {
+	return client.OnAfterResponse(observeResponse).OnError(observeError)
+}
+
+func observeResponse(_ *resty.Client, resp *resty.Response) error {
+	observe(statusOutcome(resp.StatusCode()), resp.Time())
+	return nil
+}
+
+func observeError(req *resty.Request, err error) {
+	var respErr *resty.ResponseError
+	if req.Context().Err() != nil || !errors.As(err, &respErr) || respErr.Response.RawResponse != nil {
+		// прерванный вызывающим запрос ничего не говорит о системе начислений,
+		// а полученный ответ уже учтён в observeResponse
+		return
+	}
+	observe(metrics.OutcomeError, respErr.Response.Time())
+}
+
+func observe(outcome string, d time.Duration) {
+	metrics.AccrualRequestDuration.WithLabelValues(outcome).Observe(d.Seconds())
+}
+
+func statusOutcome(status int) string {
+	switch status {
+	case http.StatusOK:
+		return metrics.OutcomeOK
+	case http.StatusNoContent:
+		return metrics.OutcomeNoContent
+	case http.StatusTooManyRequests:
+		return metrics.OutcomeTooManyRequests
+	case http.StatusInternalServerError:
+		return metrics.OutcomeServerError
+	default:
+		return metrics.OutcomeError
+	}
+}
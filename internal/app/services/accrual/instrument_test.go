@@ -0,0 +1,74 @@
+package accrual
+
+import (
+	"context"
+	"gophermart/internal/metrics"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusOutcome(t *testing.T) {
+	tests := []struct {
+		expected string
+		status   int
+	}{
+		{status: http.StatusOK, expected: metrics.OutcomeOK},
+		{status: http.StatusNoContent, expected: metrics.OutcomeNoContent},
+		{status: http.StatusTooManyRequests, expected: metrics.OutcomeTooManyRequests},
+		{status: http.StatusInternalServerError, expected: metrics.OutcomeServerError},
+		{status: http.StatusBadGateway, expected: metrics.OutcomeError},
+		{status: http.StatusNotFound, expected: metrics.OutcomeError},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			assert.Equal(t, tt.expected, statusOutcome(tt.status))
+		})
+	}
+}
+
+func TestWithMetrics_ExcludesGateWait(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	gate := NewGate(0)
+	gate.Pause(300 * time.Millisecond)
+	client := NewClient(server.URL, time.Second, gate)
+	before := histogram(t, metrics.OutcomeError)
+
+	_, err := client.GetOrder(context.Background(), 123)
+	require.Error(t, err)
+
+	after := histogram(t, metrics.OutcomeError)
+	assert.Equal(t, before.GetSampleCount()+1, after.GetSampleCount())
+	assert.Less(t, after.GetSampleSum()-before.GetSampleSum(), 0.2)
+}
+
+func TestWithMetrics_CountsNetworkErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	client := NewClient(server.URL, time.Second, NewGate(0))
+	before := histogram(t, metrics.OutcomeError)
+
+	_, err := client.GetOrder(context.Background(), 123)
+	require.ErrorIs(t, err, ErrNetwork)
+
+	assert.Equal(t, before.GetSampleCount()+1, histogram(t, metrics.OutcomeError).GetSampleCount())
+}
+
+func histogram(t *testing.T, outcome string) *dto.Histogram {
+	t.Helper()
+	var m dto.Metric
+	observer := metrics.AccrualRequestDuration.WithLabelValues(outcome).(prometheus.Metric)
+	require.NoError(t, observer.Write(&m))
+	return m.GetHistogram()
+}
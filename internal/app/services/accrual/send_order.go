@@ -1,27 +1,62 @@
 package accrual
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"gophermart/internal/app/money"
 	"net/http"
 
 	"github.com/go-resty/resty/v2"
 
 	"strconv"
+	"time"
 )
 
 type OrderResponse struct {
-	Accrual *float64 `json:"accrual,omitempty"`
-	Order   string   `json:"order"`
-	Status  string   `json:"status"`
+	Accrual *money.Money `json:"accrual,omitempty"`
+	Order   string       `json:"order"`
+	Status  string       `json:"status"`
 }
 
 var (
 	ErrServerError = errors.New("internal server error (500)")
 	ErrNoContent   = errors.New("no content (204)")
+	ErrNetwork     = errors.New("accrual system is unreachable")
 )
 
+// ErrorCategory — причина неудачного запроса, сохраняется вместе с заданием.
+type ErrorCategory string
+
+const (
+	CategoryNoContent   ErrorCategory = "no_content"   // 204 — заказ не зарегистрирован в системе начислений
+	CategoryRateLimited ErrorCategory = "rate_limited" // 429
+	CategoryServerError ErrorCategory = "server_error" // 500
+	CategoryNetwork     ErrorCategory = "network"      // таймаут или обрыв соединения
+	CategoryUnexpected  ErrorCategory = "unexpected"   // неизвестный статус или некорректное тело ответа
+	CategoryCircuitOpen ErrorCategory = "circuit_open" // запрос не отправлялся, предохранитель разомкнут
+)
+
+func Categorize(err error) ErrorCategory {
+	var tooManyReqErr *TooManyRequestsWithRetryError
+	var circuitOpenErr *CircuitOpenError
+	switch {
+	case errors.Is(err, ErrNoContent):
+		return CategoryNoContent
+	case errors.As(err, &tooManyReqErr):
+		return CategoryRateLimited
+	case errors.Is(err, ErrServerError):
+		return CategoryServerError
+	case errors.Is(err, ErrNetwork):
+		return CategoryNetwork
+	case errors.As(err, &circuitOpenErr):
+		return CategoryCircuitOpen
+	default:
+		return CategoryUnexpected
+	}
+}
+
 type TooManyRequestsWithRetryError struct {
 	RetryAfter int
 }
@@ -30,12 +65,28 @@ func (e *TooManyRequestsWithRetryError) Error() string {
 	return fmt.Sprintf("too many requests (429), retry after %d seconds", e.RetryAfter)
 }
 
-func SendOrder(client *resty.Client, orderID int) (*OrderResponse, error) {
-	resp, err := client.R().
-		Get("/api/orders/" + strconv.Itoa(orderID))
+// Client — клиент системы расчёта начислений.
+type Client interface {
+	// GetOrder запрашивает статус расчёта по номеру заказа.
+	GetOrder(ctx context.Context, number int) (*OrderResponse, error)
+}
+
+type restyClient struct {
+	client *resty.Client
+}
+
+// NewRestyClient оборачивает готовый resty-клиент; базовый адрес и хуки настраиваются вызывающим.
+func NewRestyClient(client *resty.Client) Client {
+	return &restyClient{client: client}
+}
+
+func (c *restyClient) GetOrder(ctx context.Context, number int) (*OrderResponse, error) {
+	resp, err := c.client.R().
+		SetContext(ctx).
+		Get("/api/orders/" + strconv.Itoa(number))
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to send order: %w", err)
+		return nil, fmt.Errorf("failed to send order: %w: %w", ErrNetwork, err)
 	}
 	switch resp.StatusCode() {
 	case http.StatusOK:
@@ -60,11 +111,6 @@ func handleStatusOkRequests(resp *resty.Response) (*OrderResponse, error) {
 }
 
 func handleTooManyRequests(resp *resty.Response) error {
-	retryAfter := 30
-	if header := resp.Header().Get("Retry-After"); header != "" {
-		if seconds, err := strconv.Atoi(header); err == nil {
-			retryAfter = seconds
-		}
-	}
-	return &TooManyRequestsWithRetryError{RetryAfter: retryAfter}
+	retryAfter := parseRetryAfter(resp.Header().Get("Retry-After"), time.Now())
+	return &TooManyRequestsWithRetryError{RetryAfter: int(retryAfter.Round(time.Second) / time.Second)}
 }
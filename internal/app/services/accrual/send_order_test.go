@@ -1,18 +1,21 @@
 package accrual
 
 import (
+	"context"
 	"errors"
+	"gophermart/internal/app/money"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/assert"
 )
 
-func TestSendOrder_Success(t *testing.T) {
-	accrual := 500.0
+func TestGetOrder_Success(t *testing.T) {
+	accrual := money.FromMinorUnits(50000)
 	tests := []struct {
 		name           string
 		responseCode   int
@@ -47,7 +50,7 @@ func TestSendOrder_Success(t *testing.T) {
 
 			client := resty.New().SetBaseURL(server.URL)
 
-			result, err := SendOrder(client, 123)
+			result, err := NewRestyClient(client).GetOrder(context.Background(), 123)
 
 			assert.NoError(t, err)
 			assert.Equal(t, tt.expectedResult, result)
@@ -55,7 +58,7 @@ func TestSendOrder_Success(t *testing.T) {
 	}
 }
 
-func TestSendOrder_SimpleErrors(t *testing.T) {
+func TestGetOrder_SimpleErrors(t *testing.T) {
 	tests := []struct {
 		name          string
 		responseCode  int
@@ -90,7 +93,7 @@ func TestSendOrder_SimpleErrors(t *testing.T) {
 
 			client := resty.New().SetBaseURL(server.URL)
 
-			result, err := SendOrder(client, 123)
+			result, err := NewRestyClient(client).GetOrder(context.Background(), 123)
 
 			assert.ErrorIs(t, err, tt.expectedError)
 			assert.Nil(t, result)
@@ -98,7 +101,7 @@ func TestSendOrder_SimpleErrors(t *testing.T) {
 	}
 }
 
-func TestSendOrder_CustomErrors(t *testing.T) {
+func TestGetOrder_CustomErrors(t *testing.T) {
 	tests := []struct {
 		name          string
 		responseCode  int
@@ -127,7 +130,7 @@ func TestSendOrder_CustomErrors(t *testing.T) {
 
 			client := resty.New().SetBaseURL(server.URL)
 
-			result, err := SendOrder(client, 123)
+			result, err := NewRestyClient(client).GetOrder(context.Background(), 123)
 
 			assert.Error(t, err)
 			var tooManyErr *TooManyRequestsWithRetryError
@@ -138,3 +141,26 @@ func TestSendOrder_CustomErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestGetOrder_CancelledContext(t *testing.T) {
+	released := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-released:
+		}
+	}))
+	defer server.Close()
+	defer close(released)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+
+	result, err := NewRestyClient(resty.New().SetBaseURL(server.URL)).GetOrder(ctx, 123)
+
+	assert.ErrorIs(t, err, ErrNetwork)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Nil(t, result)
+	assert.Less(t, time.Since(start), time.Second)
+}
@@ -0,0 +1,49 @@
+package accrual
+
+import (
+	"context"
+	"gophermart/internal/accrualsim"
+	"gophermart/internal/app/money"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_AgainstSimulator(t *testing.T) {
+	sim, err := accrualsim.New(accrualsim.Config{
+		Rules:         []accrualsim.Rule{{Match: "Bork", Reward: money.FromMinorUnits(1000), RewardType: "%"}},
+		ProcessingFor: 50 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	server := httptest.NewServer(sim.Handler())
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/api/orders", "application/json",
+		strings.NewReader(`{"order": "24141463521", "goods": [{"description": "Bork2", "price": 8000}]}`))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	client := NewClient(server.URL, time.Second, NewGate(0))
+	ctx := context.Background()
+
+	order, err := client.GetOrder(ctx, 24141463521)
+	require.NoError(t, err)
+	assert.Equal(t, "PROCESSING", order.Status)
+	assert.Nil(t, order.Accrual)
+
+	require.Eventually(t, func() bool {
+		order, err = client.GetOrder(ctx, 24141463521)
+		return err == nil && order.Status == "PROCESSED"
+	}, time.Second, 10*time.Millisecond)
+	require.NotNil(t, order.Accrual)
+	assert.Equal(t, money.FromMinorUnits(80000), *order.Accrual)
+
+	_, err = client.GetOrder(ctx, 79927398713)
+	assert.ErrorIs(t, err, ErrNoContent)
+}
@@ -0,0 +1,312 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gophermart/internal/app/entities"
+	"gophermart/internal/app/money"
+	"gophermart/internal/app/repositories"
+	"gophermart/internal/app/services/accrual"
+	"gophermart/internal/app/utils"
+	"gophermart/internal/config"
+	"gophermart/internal/metrics"
+	"gophermart/internal/tracing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// AccrualCallbackService применяет уведомления системы начислений и ставит заказы на повторную проверку.
+// Запросов к системе начислений он не делает, поэтому клиент ему не нужен.
+type AccrualCallbackService interface {
+	ApplyCallback(ctx context.Context, orderNumber int64, orderResponse *accrual.OrderResponse) error
+	EnqueueReverification(ctx context.Context, filter entities.ReverificationFilter) (entities.ReverificationResult, error)
+}
+
+// categoryCorrectionExceedsBalance — причина dead-letter, когда корректировка увела бы баланс в минус.
+const categoryCorrectionExceedsBalance = "correction_exceeds_balance"
+
+// accrualApplier применяет ответы системы начислений, полученные опросом или уведомлением.
+type accrualApplier struct {
+	Pool                   TxBeginner
+	JobRepository          repositories.JobRepositoryInterface
+	OrderRepository        repositories.OrderRepositoryInterface
+	UserRepository         repositories.UserRepositoryInterface
+	BalanceEntryRepository repositories.BalanceEntryRepositoryInterface
+	Cfg                    *config.Config
+	Logger                 *zap.SugaredLogger
+}
+
+func NewAccrualCallbackService(
+	db TxBeginner,
+	jobRepository repositories.JobRepositoryInterface,
+	orderRepository repositories.OrderRepositoryInterface,
+	userRepository repositories.UserRepositoryInterface,
+	balanceEntryRepository repositories.BalanceEntryRepositoryInterface,
+	cfg *config.Config,
+	logger *zap.SugaredLogger,
+) AccrualCallbackService {
+	return &accrualApplier{
+		Pool:                   db,
+		JobRepository:          jobRepository,
+		OrderRepository:        orderRepository,
+		UserRepository:         userRepository,
+		BalanceEntryRepository: balanceEntryRepository,
+		Cfg:                    cfg,
+		Logger:                 logger,
+	}
+}
+
+// ApplyCallback применяет уведомление системы начислений тем же путём, что и ответ на опрос.
+// В конечном статусе задание заказа удаляется; иначе опрос продолжается по расписанию на случай,
+// если следующее уведомление не придёт.
+func (a *accrualApplier) ApplyCallback(
+	ctx context.Context,
+	orderNumber int64,
+	orderResponse *accrual.OrderResponse,
+) (err error) {
+	ctx, span := tracer.Start(ctx, "AccrualCallbackService.ApplyCallback")
+	defer func() { tracing.End(span, err) }()
+
+	statusID, err := entities.StatusFromAccrual(orderResponse.Status)
+	if err != nil {
+		return fmt.Errorf("failed to apply accrual callback: %w", err)
+	}
+	order, err := a.OrderRepository.GetByOrderNumber(ctx, orderNumber)
+	if err != nil {
+		return fmt.Errorf("failed to GetByOrderNumber %d: %w", orderNumber, err)
+	}
+	return a.applyResponse(ctx, int64(order.ID), nil, statusID, orderResponse)
+}
+
+// applyResponse в одной короткой транзакции обновляет заказ, начисляет баллы и удаляет
+// или откладывает задание. Строка заказа блокируется, чтобы переход статуса проверялся по актуальному состоянию.
+// job равен nil, если ответ пришёл уведомлением, а не опросом.
+func (a *accrualApplier) applyResponse(
+	ctx context.Context,
+	orderID int64,
+	job *entities.Job,
+	statusID int,
+	orderResponse *accrual.OrderResponse,
+) error {
+	tx, err := a.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func(tx pgx.Tx, ctx context.Context) {
+		_ = tx.Rollback(ctx)
+	}(tx, ctx)
+
+	order, err := a.OrderRepository.LockByID(ctx, tx, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to LockByID: %w", err)
+	}
+	// заказ мог уже получить начисление из уведомления, пока шёл опрос, или наоборот,
+	// либо попасть в очередь на повторную проверку
+	alreadyProcessed := order.StatusID == entities.StatusProcessed
+	// проведённое начисление меняет только задание повторной проверки:
+	// повторный или запоздавший ответ не должен трогать давно рассчитанный баланс
+	reverifying := alreadyProcessed && job != nil && job.Reverify
+	previousAccrual := order.Accrual
+	if err = order.TransitionTo(statusID); err != nil {
+		return fmt.Errorf("failed to apply accrual status: %w", err)
+	}
+	received := money.NullMoney{Valid: false}
+	if orderResponse.Accrual != nil {
+		received = money.NullMoney{Money: *orderResponse.Accrual, Valid: true}
+	}
+	correction := accrualAmount(received) - accrualAmount(previousAccrual)
+	if alreadyProcessed && !reverifying {
+		return a.ignoreStaleResponse(ctx, tx, order, job, received)
+	}
+	if reverifying && correction < 0 {
+		balance, err := a.UserRepository.LockBalanceByUserID(ctx, tx, order.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to lock balance: %w", err)
+		}
+		if balance+correction < 0 {
+			return a.rejectCorrection(ctx, tx, order, job, correction, balance)
+		}
+	}
+	order.Accrual = received
+	order.UpdatedAt = time.Now()
+	if reverifying && correction != 0 {
+		correctedAt := order.UpdatedAt
+		order.PreviousAccrual = previousAccrual
+		order.AccrualCorrectedAt = &correctedAt
+	}
+	err = a.OrderRepository.UpdateOrder(ctx, tx, order)
+	if err != nil {
+		utils.ContextLogger(ctx, a.Logger).Infoln(err)
+		return fmt.Errorf("failed to UpdateOrder with status: %w", err)
+	}
+
+	if !alreadyProcessed && a.isLoyaltyPoint(order) {
+		entry := entities.BalanceEntry{
+			UserID:  order.UserID,
+			TypeID:  entities.EntryTypeAccrual,
+			Amount:  order.Accrual.Money,
+			OrderID: sql.NullInt64{Int64: orderID, Valid: true},
+		}
+		err = postBalanceEntry(ctx, tx, a.BalanceEntryRepository, a.UserRepository, &entry)
+		if err != nil {
+			return fmt.Errorf("failed to accrue points for user %d: %w", order.UserID, err)
+		}
+	}
+	if reverifying && correction != 0 {
+		// алгоритм начисления изменился: компенсируем разницу, исходная проводка остаётся в журнале
+		utils.ContextLogger(ctx, a.Logger).Infow("accrual corrected",
+			"order", order.OrderID, "previous", previousAccrual.Money.String(), "accrual", order.Accrual.Money.String())
+		entry := entities.BalanceEntry{
+			UserID:  order.UserID,
+			TypeID:  entities.EntryTypeAdjustment,
+			Amount:  correction,
+			OrderID: sql.NullInt64{Int64: orderID, Valid: true},
+			Reason: sql.NullString{
+				String: fmt.Sprintf("accrual for order %d corrected from %s to %s",
+					order.OrderID, accrualAmount(previousAccrual), accrualAmount(order.Accrual)),
+				Valid: true,
+			},
+		}
+		err = postBalanceEntry(ctx, tx, a.BalanceEntryRepository, a.UserRepository, &entry)
+		if err != nil {
+			return fmt.Errorf("failed to correct accrual for user %d: %w", order.UserID, err)
+		}
+	}
+
+	switch {
+	case entities.IsTerminalStatus(int(order.StatusID)) && job == nil:
+		err = a.JobRepository.DeleteJobByOrderID(ctx, tx, orderID)
+		if err != nil {
+			return fmt.Errorf("failed to DeleteJobByOrderID: %w", err)
+		}
+	case entities.IsTerminalStatus(int(order.StatusID)):
+		err = a.JobRepository.DeleteJobByID(ctx, tx, job.ID)
+		if err != nil {
+			utils.ContextLogger(ctx, a.Logger).Infoln(err)
+			return fmt.Errorf("failed to DeleteJobByID: %w", err)
+		}
+	case job != nil:
+		// расчёт ещё идёт: опрашиваем снова, каждый раз выжидая дольше
+		next := *job
+		next.Polls = job.Polls + 1
+		nextAttemptAt := time.Now().Add(retryDelay(next.Polls, a.Cfg.AgentBackoffBase, a.Cfg.AgentBackoffMax))
+		next.NextAttemptAt = &nextAttemptAt
+		err = a.JobRepository.ScheduleNextPoll(ctx, tx, &next, a.Cfg.AgentWorkerID)
+		if err != nil {
+			return fmt.Errorf("failed to ScheduleNextPoll: %w", err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if !alreadyProcessed && a.isLoyaltyPoint(order) {
+		metrics.PointsAccrued.Add(order.Accrual.Money.Float64())
+	}
+	if reverifying && correction != 0 {
+		direction, amount := correctionDirection(correction)
+		metrics.PointsCorrected.WithLabelValues(direction).Add(amount.Float64())
+	}
+	if job != nil && entities.IsTerminalStatus(int(order.StatusID)) {
+		metrics.JobsCompleted.Inc()
+	}
+	return nil
+}
+
+// ignoreStaleResponse завершает обработку повторного или запоздавшего ответа по уже обработанному заказу:
+// заказ и баланс не меняются. Задание опроса удаляется, а уведомление не трогает
+// ожидающее задание повторной проверки.
+func (a *accrualApplier) ignoreStaleResponse(
+	ctx context.Context,
+	tx pgx.Tx,
+	order *entities.Order,
+	job *entities.Job,
+	received money.NullMoney,
+) error {
+	if accrualAmount(received) != accrualAmount(order.Accrual) {
+		utils.ContextLogger(ctx, a.Logger).Warnw("stale accrual response ignored",
+			"order", order.OrderID, "accrual", accrualAmount(order.Accrual).String(),
+			"received", accrualAmount(received).String())
+	}
+	if job != nil {
+		if err := a.JobRepository.DeleteJobByID(ctx, tx, job.ID); err != nil {
+			return fmt.Errorf("failed to DeleteJobByID: %w", err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	if job != nil {
+		metrics.JobsCompleted.Inc()
+	}
+	return nil
+}
+
+// rejectCorrection переводит задание повторной проверки в dead-letter, если уменьшение начисления
+// увело бы баланс в минус: баллы уже потрачены, и решение остаётся за оператором.
+// Заказ и баланс не меняются; после разбора задание можно снова поставить в очередь.
+func (a *accrualApplier) rejectCorrection(
+	ctx context.Context,
+	tx pgx.Tx,
+	order *entities.Order,
+	job *entities.Job,
+	correction money.Money,
+	balance money.Money,
+) error {
+	utils.ContextLogger(ctx, a.Logger).Warnw("accrual correction exceeds balance, job moved to dead-letter",
+		"job_id", job.ID, "order", order.OrderID, "user", order.UserID,
+		"balance", balance.String(), "correction", correction.String())
+	lastError := fmt.Sprintf("correction %s exceeds balance %s", correction, balance)
+	lastErrorCategory := categoryCorrectionExceedsBalance
+	failed := *job
+	failed.LastError = &lastError
+	failed.LastErrorCategory = &lastErrorCategory
+	if err := a.JobRepository.MarkJobDead(ctx, tx, &failed, a.Cfg.AgentWorkerID); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	metrics.JobsDeadLettered.Inc()
+	return nil
+}
+
+// correctionDirection разделяет корректировку на направление и сумму: счётчик Prometheus не уменьшается.
+func correctionDirection(correction money.Money) (string, money.Money) {
+	if correction > 0 {
+		return "up", correction
+	}
+	return "down", -correction
+}
+
+// accrualAmount считает отсутствующее начисление нулевым.
+func accrualAmount(accrual money.NullMoney) money.Money {
+	if !accrual.Valid {
+		return 0
+	}
+	return accrual.Money
+}
+
+func (a *accrualApplier) isLoyaltyPoint(order *entities.Order) bool {
+	return order.StatusID == entities.StatusProcessed && order.Accrual.Valid && order.Accrual.Money > 0
+}
+
+// EnqueueReverification ставит обработанные заказы в очередь на повторный запрос начисления.
+// Если система начислений вернёт другую сумму, разница будет проведена корректировкой баланса.
+func (a *accrualApplier) EnqueueReverification(
+	ctx context.Context,
+	filter entities.ReverificationFilter,
+) (_ entities.ReverificationResult, err error) {
+	ctx, span := tracer.Start(ctx, "AccrualCallbackService.EnqueueReverification")
+	defer func() { tracing.End(span, err) }()
+
+	result, err := a.JobRepository.EnqueueReverification(ctx, filter)
+	if err != nil {
+		return entities.ReverificationResult{}, fmt.Errorf("failed to EnqueueReverification: %w", err)
+	}
+	return result, nil
+}
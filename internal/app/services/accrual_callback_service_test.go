@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"gophermart/internal/app/entities"
+	"gophermart/internal/app/money"
+	"gophermart/internal/app/services/accrual"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestAccrualCallbackServiceApplyCallback(t *testing.T) {
+	accrued := money.FromMinorUnits(500)
+	tests := []struct {
+		name            string
+		status          string
+		previousAccrual money.Money
+		currentStatus   int16
+		expectUpdate    bool
+		expectAccrual   bool
+		expectDelete    bool
+	}{
+		{
+			name:          "processed order accrues points and drops the job",
+			status:        "PROCESSED",
+			currentStatus: entities.StatusProcessing,
+			expectUpdate:  true,
+			expectAccrual: true,
+			expectDelete:  true,
+		},
+		{
+			name:            "repeated notification does not accrue twice",
+			status:          "PROCESSED",
+			currentStatus:   entities.StatusProcessed,
+			previousAccrual: accrued,
+		},
+		{
+			name:            "replayed notification with another amount does not correct the balance",
+			status:          "PROCESSED",
+			currentStatus:   entities.StatusProcessed,
+			previousAccrual: money.FromMinorUnits(900),
+		},
+		{
+			name:          "intermediate status keeps polling as a fallback",
+			status:        "PROCESSING",
+			currentStatus: entities.StatusNew,
+			expectUpdate:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newServiceDeps(t)
+			order := d.serveOrder()
+			service := NewAccrualCallbackService(d.beginner, d.jobRepo, d.orderRepo, d.userRepo, d.entryRepo,
+				testAccrualConfig, zap.NewNop().Sugar())
+			order.StatusID = tt.currentStatus
+			if tt.currentStatus == entities.StatusProcessed {
+				order.Accrual = money.NullMoney{Money: tt.previousAccrual, Valid: true}
+			}
+			d.orderRepo.EXPECT().GetByOrderNumber(gomock.Any(), int64(testOrderNumber)).Return(order, nil)
+			if tt.expectUpdate {
+				d.orderRepo.EXPECT().UpdateOrder(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			}
+			if tt.expectAccrual {
+				d.userRepo.EXPECT().LockBalanceByUserID(gomock.Any(), gomock.Any(), int64(7)).Return(money.Money(0), nil)
+				d.entryRepo.EXPECT().Store(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				d.userRepo.EXPECT().AddBalanceByUserID(gomock.Any(), gomock.Any(), accrued, int64(7)).Return(nil)
+			}
+			if tt.expectDelete {
+				d.jobRepo.EXPECT().DeleteJobByOrderID(gomock.Any(), gomock.Any(), int64(testOrderID)).Return(nil)
+			}
+
+			err := service.ApplyCallback(context.Background(), testOrderNumber, &accrual.OrderResponse{
+				Order:   "12345678903",
+				Status:  tt.status,
+				Accrual: &accrued,
+			})
+
+			require.NoError(t, err)
+			if !tt.expectUpdate {
+				assert.Equal(t, money.NullMoney{Money: tt.previousAccrual, Valid: true}, order.Accrual)
+			}
+			require.Len(t, d.beginner.txs, 1)
+			assert.True(t, d.beginner.txs[0].committed)
+		})
+	}
+}
@@ -2,135 +2,231 @@ package services
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
 	"gophermart/internal/app/entities"
+	"gophermart/internal/app/money"
 	"gophermart/internal/app/services/accrual"
-	"math"
+	"gophermart/internal/app/utils"
+	"gophermart/internal/metrics"
+	"gophermart/internal/tracing"
 	"time"
 
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
-	"github.com/go-resty/resty/v2"
 	"go.uber.org/zap"
 
 	"gophermart/internal/app/repositories"
 	"gophermart/internal/config"
 )
 
+// applyTimeout ограничивает транзакцию, применяющую уже полученный ответ системы начислений.
+const applyTimeout = 5 * time.Second
+
 type AccrualService interface {
 	SendOrder(ctx context.Context, job *entities.Job) error
-	GetPendingJobs(ctx context.Context, limit int) ([]entities.Job, error)
+	ClaimJobs(ctx context.Context, limit int) ([]entities.Job, error)
+	ReleaseJob(ctx context.Context, job *entities.Job) error
+	ExtendLease(ctx context.Context, job *entities.Job) (bool, error)
 }
 
+// accrualService опрашивает систему начислений и применяет ответы так же, как accrualApplier — уведомления.
 type accrualService struct {
-	Pool            *pgxpool.Pool
-	JobRepository   repositories.JobRepositoryInterface
-	OrderRepository repositories.OrderRepositoryInterface
-	UserRepository  repositories.UserRepositoryInterface
-	Client          *resty.Client
-	Cfg             *config.Config
-	Logger          *zap.SugaredLogger
-	roundingFactor  float64
+	accrualApplier
+	Client accrual.Client
 }
 
 func NewAccrualService(
-	db *pgxpool.Pool,
+	db TxBeginner,
 	jobRepository repositories.JobRepositoryInterface,
 	orderRepository repositories.OrderRepositoryInterface,
 	userRepository repositories.UserRepositoryInterface,
-	client *resty.Client,
+	balanceEntryRepository repositories.BalanceEntryRepositoryInterface,
+	client accrual.Client,
 	cfg *config.Config,
 	logger *zap.SugaredLogger,
 ) AccrualService {
-	const roundingFactor = 100
 	return &accrualService{
-		Pool:            db,
-		JobRepository:   jobRepository,
-		OrderRepository: orderRepository,
-		UserRepository:  userRepository,
-		Client:          client,
-		Cfg:             cfg,
-		Logger:          logger,
-		roundingFactor:  roundingFactor,
+		accrualApplier: accrualApplier{
+			Pool:                   db,
+			JobRepository:          jobRepository,
+			OrderRepository:        orderRepository,
+			UserRepository:         userRepository,
+			BalanceEntryRepository: balanceEntryRepository,
+			Cfg:                    cfg,
+			Logger:                 logger,
+		},
+		Client: client,
 	}
 }
 
-func (a *accrualService) SendOrder(ctx context.Context, job *entities.Job) error {
-	tx, err := a.Pool.Begin(ctx)
+// SendOrder запрашивает статус заказа у системы начислений и применяет ответ.
+// Запрос выполняется вне транзакции, чтобы медленная система начислений не держала соединения пула.
+func (a *accrualService) SendOrder(ctx context.Context, job *entities.Job) (err error) {
+	ctx, span := tracer.Start(ctx, "AccrualService.SendOrder", trace.WithAttributes(
+		attribute.Int64("job.id", job.ID),
+		attribute.Int("job.attempts", job.Attempts),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	order, err := a.OrderRepository.GetByID(ctx, nil, job.OrderID)
 	if err != nil {
-		return fmt.Errorf("failed to start transaction: %w", err)
+		return fmt.Errorf("failed to GetById to accrual: %w", err)
 	}
-	defer func(tx pgx.Tx, ctx context.Context) {
-		_ = tx.Rollback(ctx)
-	}(tx, ctx)
 
-	order, err := a.OrderRepository.GetByID(ctx, tx, job.OrderID)
+	orderResponse, err := a.Client.GetOrder(ctx, order.OrderID)
 	if err != nil {
-		return fmt.Errorf("failed to GetById to accrual: %w", err)
+		utils.ContextLogger(ctx, a.Logger).Infoln(err)
+		return a.recordFailure(ctx, job, err)
 	}
 
-	orderResponse, err := accrual.SendOrder(a.Client, order.OrderID)
+	statusID, err := entities.StatusFromAccrual(orderResponse.Status)
 	if err != nil {
-		a.Logger.Infoln(err)
-		err = a.JobRepository.UpdateJobPoolAt(ctx, tx, job.ID)
-		return fmt.Errorf("failed to SendOrder to accrual: %w", err)
+		// ответ не применить к заказу: считаем его ошибкой системы начислений и повторяем позже
+		utils.ContextLogger(ctx, a.Logger).Infoln(err)
+		return a.recordFailure(ctx, job, err)
+	}
+
+	// ответ уже получен: сохраняем его, даже если воркер останавливается
+	applyCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), applyTimeout)
+	defer cancel()
+	err = a.applyResponse(applyCtx, job.OrderID, job, statusID, orderResponse)
+	var transitionErr *entities.TransitionError
+	if errors.As(err, &transitionErr) {
+		utils.ContextLogger(ctx, a.Logger).Infoln(err)
+		return a.recordFailure(applyCtx, job, err)
 	}
+	return err
+}
 
-	statusID, _ := entities.GetStatusIDByName(orderResponse.Status)
-	if orderResponse.Accrual != nil {
-		roundedAmount := math.Round(*orderResponse.Accrual*a.roundingFactor) / a.roundingFactor
-		order.Accrual = sql.NullFloat64{Float64: roundedAmount, Valid: true}
-	} else {
-		order.Accrual = sql.NullFloat64{Valid: false}
+// recordFailure фиксирует неудачную попытку в отдельной короткой транзакции.
+// После AgentMaxAttempts попыток задание уходит в dead-letter, а заказ получает статус INVALID.
+// Возвращает исходную ошибку системы начислений, к которой добавляется ошибка записи, если она была.
+func (a *accrualService) recordFailure(
+	ctx context.Context,
+	job *entities.Job,
+	sendErr error,
+) error {
+	category := accrual.Categorize(sendErr)
+	sendErr = fmt.Errorf("failed to SendOrder to accrual: %w", sendErr)
+	if ctx.Err() != nil {
+		// воркер остановлен, задание вернётся в очередь по истечении аренды
+		return sendErr
 	}
-	order.StatusID = int16(statusID)
-	order.UpdatedAt = time.Now()
-	err = a.OrderRepository.UpdateOrder(ctx, tx, order)
+
+	tx, err := a.Pool.Begin(ctx)
 	if err != nil {
-		a.Logger.Infoln(err)
-		return fmt.Errorf("failed to UpdateOrder with status: %w", err)
+		return errors.Join(sendErr, fmt.Errorf("failed to start transaction: %w", err))
 	}
+	defer func(tx pgx.Tx, ctx context.Context) {
+		_ = tx.Rollback(ctx)
+	}(tx, ctx)
 
-	if a.isLoyaltyPoint(order) {
-		var currentBalance float64
-		currentBalance, err = a.UserRepository.GetBalanceByUserID(ctx, tx, order.UserID)
-		if err != nil {
-			return fmt.Errorf("failed to get current balance for user %d: %w", order.UserID, err)
-		}
-		newBalance := currentBalance + order.Accrual.Float64
-		err = a.UserRepository.UpdateBalanceByUserID(ctx, tx, newBalance, order.UserID)
-		if err != nil {
-			return fmt.Errorf(
-				"failed to update user balance for user %d: %w",
-				order.UserID,
-				err,
-			)
-		}
-	}
-
-	err = a.JobRepository.DeleteJobByID(ctx, tx, job.ID)
+	lastError := sendErr.Error()
+	lastErrorCategory := string(category)
+	failed := *job
+	failed.Attempts = job.Attempts + 1
+	failed.LastError = &lastError
+	failed.LastErrorCategory = &lastErrorCategory
+
+	var tooManyReqErr *accrual.TooManyRequestsWithRetryError
+	var circuitOpenErr *accrual.CircuitOpenError
+	deadLettered := false
+	switch {
+	case errors.As(sendErr, &tooManyReqErr):
+		// ограничение частоты запросов не говорит о проблеме с заказом, попытка не засчитывается
+		failed.Attempts = job.Attempts
+		nextAttemptAt := time.Now().Add(time.Duration(tooManyReqErr.RetryAfter) * time.Second)
+		failed.NextAttemptAt = &nextAttemptAt
+		err = a.JobRepository.ScheduleRetry(ctx, tx, &failed, a.Cfg.AgentWorkerID)
+	case errors.As(sendErr, &circuitOpenErr):
+		// запрос не отправлялся: откладываем задание до конца паузы, попытка не засчитывается
+		failed.Attempts = job.Attempts
+		failed.NextAttemptAt = &circuitOpenErr.Until
+		err = a.JobRepository.ScheduleRetry(ctx, tx, &failed, a.Cfg.AgentWorkerID)
+	case failed.Attempts >= a.Cfg.AgentMaxAttempts:
+		err = a.deadLetter(ctx, tx, &failed)
+		deadLettered = true
+	default:
+		nextAttemptAt := time.Now().Add(retryDelay(failed.Attempts, a.Cfg.AgentBackoffBase, a.Cfg.AgentBackoffMax))
+		failed.NextAttemptAt = &nextAttemptAt
+		err = a.JobRepository.ScheduleRetry(ctx, tx, &failed, a.Cfg.AgentWorkerID)
+	}
 	if err != nil {
-		a.Logger.Infoln(err)
-		return fmt.Errorf("failed to DeleteJobByID: %w", err)
+		return errors.Join(sendErr, err)
 	}
 
 	if err = tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return errors.Join(sendErr, fmt.Errorf("failed to commit transaction: %w", err))
 	}
 
-	return nil
+	metrics.JobsFailed.WithLabelValues(string(category)).Inc()
+	if deadLettered {
+		metrics.JobsDeadLettered.Inc()
+	}
+	return sendErr
 }
 
-func (a *accrualService) isLoyaltyPoint(order *entities.Order) bool {
-	return order.Accrual.Valid && order.Accrual.Float64 > 0
+// deadLetter переводит задание в dead-letter, а его заказ — в INVALID.
+// Заказ блокируется и читается заново: пока шёл запрос, уведомление могло довести его до конечного статуса.
+// Как и в applyResponse, заказ блокируется раньше задания, чтобы транзакции не ждали друг друга по кругу.
+func (a *accrualService) deadLetter(ctx context.Context, tx pgx.Tx, failed *entities.Job) error {
+	order, err := a.OrderRepository.LockByID(ctx, tx, failed.OrderID)
+	if err != nil {
+		return fmt.Errorf("failed to LockByID: %w", err)
+	}
+	utils.ContextLogger(ctx, a.Logger).Warnw("accrual job moved to dead-letter",
+		"job_id", failed.ID, "order", order.OrderID, "attempts", failed.Attempts, "category", *failed.LastErrorCategory)
+	if err = a.JobRepository.MarkJobDead(ctx, tx, failed, a.Cfg.AgentWorkerID); err != nil {
+		return err
+	}
+	// заказ в конечном статусе не трогаем, иначе потеряется уже рассчитанное начисление
+	if entities.IsTerminalStatus(int(order.StatusID)) {
+		return nil
+	}
+	order.StatusID = entities.StatusInvalid
+	order.Accrual = money.NullMoney{Valid: false}
+	if err = a.OrderRepository.UpdateOrder(ctx, tx, order); err != nil {
+		return fmt.Errorf("failed to UpdateOrder with status: %w", err)
+	}
+	return nil
 }
 
-func (a *accrualService) GetPendingJobs(ctx context.Context, limit int) ([]entities.Job, error) {
-	jobs, err := a.JobRepository.GetPendingJobs(ctx, nil, limit)
+// ClaimJobs берёт задания в аренду на AgentLeaseTimeout, чтобы их не обработал другой экземпляр сервиса.
+func (a *accrualService) ClaimJobs(ctx context.Context, limit int) (_ []entities.Job, err error) {
+	ctx, span := tracer.Start(ctx, "AccrualService.ClaimJobs")
+	defer func() { tracing.End(span, err) }()
+
+	jobs, err := a.JobRepository.ClaimJobs(ctx, a.Cfg.AgentWorkerID, limit, a.Cfg.AgentLeaseTimeout)
 	if err != nil {
-		return nil, fmt.Errorf("failed to GetPendingJobs: %w", err)
+		return nil, fmt.Errorf("failed to ClaimJobs: %w", err)
 	}
 	return jobs, nil
 }
+
+// ExtendLease продлевает аренду задания ещё на AgentLeaseTimeout. false означает, что задание
+// больше не принадлежит этому экземпляру и обрабатывать его не нужно.
+func (a *accrualService) ExtendLease(ctx context.Context, job *entities.Job) (_ bool, err error) {
+	ctx, span := tracer.Start(ctx, "AccrualService.ExtendLease")
+	defer func() { tracing.End(span, err) }()
+
+	extended, err := a.JobRepository.ExtendLease(ctx, job.ID, a.Cfg.AgentWorkerID, a.Cfg.AgentLeaseTimeout)
+	if err != nil {
+		return false, fmt.Errorf("failed to ExtendLease: %w", err)
+	}
+	return extended, nil
+}
+
+// ReleaseJob возвращает в очередь задание, которое не будет обработано до истечения аренды.
+func (a *accrualService) ReleaseJob(ctx context.Context, job *entities.Job) (err error) {
+	ctx, span := tracer.Start(ctx, "AccrualService.ReleaseJob")
+	defer func() { tracing.End(span, err) }()
+
+	err = a.JobRepository.ReleaseJob(ctx, nil, job.ID, a.Cfg.AgentWorkerID)
+	if err != nil {
+		return fmt.Errorf("failed to ReleaseJob: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"gophermart/internal/app/entities"
+	"gophermart/internal/app/money"
+	"gophermart/internal/app/repositories"
+	"gophermart/internal/app/services/accrual"
+	"gophermart/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSendOrder_PollsOrderToFinalAccrual(t *testing.T) {
+	db := newIntegrationDB(t)
+	ctx := context.Background()
+
+	responses := []string{"REGISTERED", "PROCESSING", "PROCESSING", "PROCESSED"}
+	var polls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		poll := int(polls.Add(1)) - 1
+		status := responses[min(poll, len(responses)-1)]
+		w.Header().Set("Content-Type", "application/json")
+		if status == "PROCESSED" {
+			_, _ = fmt.Fprintf(w, `{"order": %q, "status": %q, "accrual": 729.98}`, r.URL.Path, status)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"order": %q, "status": %q}`, r.URL.Path, status)
+	}))
+	defer server.Close()
+
+	userRepo := repositories.NewUserRepository(db.Pool)
+	orderRepo := repositories.NewOrderRepository(db.Pool)
+	jobRepo := repositories.NewJobRepository(db.Pool)
+	balanceEntryRepo := repositories.NewBalanceEntryRepository(db.Pool)
+	cfg := &config.Config{
+		AgentWorkerID:     "integration-test",
+		AgentLeaseTimeout: time.Minute,
+		AgentMaxAttempts:  3,
+		AgentBackoffBase:  time.Millisecond,
+		AgentBackoffMax:   10 * time.Millisecond,
+	}
+	client := accrual.NewClient(server.URL, time.Second, accrual.NewGate(0))
+	service := NewAccrualService(
+		db.Pool,
+		jobRepo,
+		orderRepo,
+		userRepo,
+		balanceEntryRepo,
+		client,
+		cfg,
+		zap.NewNop().Sugar(),
+	)
+
+	seed := time.Now().UnixNano()
+	user, err := userRepo.Store(ctx, entities.User{
+		Login:    fmt.Sprintf("accrual-polls-%d", seed),
+		Password: "password",
+	})
+	require.NoError(t, err)
+	order := &entities.Order{OrderID: int(seed % 1_000_000_000), UserID: int64(user.ID), StatusID: entities.StatusNew}
+	orderID, err := orderRepo.Store(ctx, nil, order)
+	require.NoError(t, err)
+	require.NoError(t, jobRepo.SaveJob(ctx, nil, &entities.Job{OrderID: int64(orderID), CreatedAt: time.Now()}))
+
+	statuses := make([]int16, 0, len(responses))
+	poll := func() (bool, error) {
+		jobs, err := service.ClaimJobs(ctx, 100)
+		if err != nil {
+			return false, err
+		}
+		done := false
+		for i := range jobs {
+			job := &jobs[i]
+			if job.OrderID != int64(orderID) {
+				if err = service.ReleaseJob(ctx, job); err != nil {
+					return false, err
+				}
+				continue
+			}
+			if err = service.SendOrder(ctx, job); err != nil {
+				return false, err
+			}
+			stored, err := orderRepo.GetByID(ctx, nil, int64(orderID))
+			if err != nil {
+				return false, err
+			}
+			statuses = append(statuses, stored.StatusID)
+			done = entities.IsTerminalStatus(int(stored.StatusID))
+		}
+		return done, nil
+	}
+	// условие Eventually выполняется в другой горутине, где require не может остановить тест,
+	// поэтому ошибка опроса прерывает ожидание и проверяется после него
+	var pollErr error
+	require.Eventually(t, func() bool {
+		var done bool
+		done, pollErr = poll()
+		return done || pollErr != nil
+	}, 5*time.Second, 5*time.Millisecond)
+	require.NoError(t, pollErr)
+
+	assert.Equal(t, []int16{
+		entities.StatusProcessing,
+		entities.StatusProcessing,
+		entities.StatusProcessing,
+		entities.StatusProcessed,
+	}, statuses)
+
+	stored, err := orderRepo.GetByID(ctx, nil, int64(orderID))
+	require.NoError(t, err)
+	assert.Equal(t, money.NullMoney{Money: money.FromMinorUnits(72998), Valid: true}, stored.Accrual)
+
+	balance, err := userRepo.GetBalanceByUserID(ctx, nil, int64(user.ID))
+	require.NoError(t, err)
+	assert.Equal(t, money.FromMinorUnits(72998), balance)
+
+	var remaining int
+	require.NoError(t, db.Pool.QueryRow(ctx, "SELECT count(*) FROM jobs WHERE order_id = $1", orderID).Scan(&remaining))
+	assert.Zero(t, remaining)
+}
+
+func TestSendOrder_DeadLetterRacingCallbackKeepsAccrual(t *testing.T) {
+	db := newIntegrationDB(t)
+	ctx := context.Background()
+
+	userRepo := repositories.NewUserRepository(db.Pool)
+	orderRepo := repositories.NewOrderRepository(db.Pool)
+	jobRepo := repositories.NewJobRepository(db.Pool)
+	balanceEntryRepo := repositories.NewBalanceEntryRepository(db.Pool)
+	cfg := &config.Config{
+		AgentWorkerID:     "integration-test",
+		AgentLeaseTimeout: time.Minute,
+		AgentMaxAttempts:  1,
+		AgentBackoffBase:  time.Millisecond,
+		AgentBackoffMax:   10 * time.Millisecond,
+	}
+	callbackService := NewAccrualCallbackService(
+		db.Pool,
+		jobRepo,
+		orderRepo,
+		userRepo,
+		balanceEntryRepo,
+		cfg,
+		zap.NewNop().Sugar(),
+	)
+
+	seed := time.Now().UnixNano()
+	user, err := userRepo.Store(ctx, entities.User{
+		Login:    fmt.Sprintf("accrual-dead-letter-%d", seed),
+		Password: "password",
+	})
+	require.NoError(t, err)
+	orderNumber := int(seed % 1_000_000_000)
+	order := &entities.Order{OrderID: orderNumber, UserID: int64(user.ID), StatusID: entities.StatusNew}
+	orderID, err := orderRepo.Store(ctx, nil, order)
+	require.NoError(t, err)
+	require.NoError(t, jobRepo.SaveJob(ctx, nil, &entities.Job{OrderID: int64(orderID), CreatedAt: time.Now()}))
+
+	// уведомление о начислении приходит, пока опрос ждёт ответа, а опрос получает 204 на последней попытке
+	accrued := money.FromMinorUnits(500)
+	callbackErr := make(chan error, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callbackErr <- callbackService.ApplyCallback(r.Context(), int64(orderNumber), &accrual.OrderResponse{
+			Order:   fmt.Sprint(orderNumber),
+			Status:  "PROCESSED",
+			Accrual: &accrued,
+		})
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	service := NewAccrualService(
+		db.Pool,
+		jobRepo,
+		orderRepo,
+		userRepo,
+		balanceEntryRepo,
+		accrual.NewClient(server.URL, time.Second, accrual.NewGate(0)),
+		cfg,
+		zap.NewNop().Sugar(),
+	)
+
+	jobs, err := service.ClaimJobs(ctx, 100)
+	require.NoError(t, err)
+	var sent bool
+	for i := range jobs {
+		job := &jobs[i]
+		if job.OrderID != int64(orderID) {
+			require.NoError(t, service.ReleaseJob(ctx, job))
+			continue
+		}
+		assert.ErrorIs(t, service.SendOrder(ctx, job), accrual.ErrNoContent)
+		sent = true
+	}
+	require.True(t, sent)
+	require.NoError(t, <-callbackErr)
+
+	stored, err := orderRepo.GetByID(ctx, nil, int64(orderID))
+	require.NoError(t, err)
+	assert.Equal(t, int16(entities.StatusProcessed), stored.StatusID)
+	assert.Equal(t, money.NullMoney{Money: accrued, Valid: true}, stored.Accrual)
+
+	balance, err := userRepo.GetBalanceByUserID(ctx, nil, int64(user.ID))
+	require.NoError(t, err)
+	assert.Equal(t, accrued, balance)
+}
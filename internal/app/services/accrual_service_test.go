@@ -0,0 +1,653 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"gophermart/internal/app/apperrors"
+	"gophermart/internal/app/entities"
+	"gophermart/internal/app/money"
+	"gophermart/internal/app/repositories/mocks"
+	"gophermart/internal/app/services/accrual"
+	"gophermart/internal/app/services/accrual/fake"
+	"gophermart/internal/config"
+	"gophermart/internal/metrics"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeTx запоминает, чем закончилась транзакция; остальные методы pgx.Tx в тестах не вызываются.
+type fakeTx struct {
+	pgx.Tx
+	committed  bool
+	rolledBack bool
+}
+
+func (tx *fakeTx) Commit(context.Context) error {
+	tx.committed = true
+	return nil
+}
+
+func (tx *fakeTx) Rollback(context.Context) error {
+	if !tx.committed {
+		tx.rolledBack = true
+	}
+	return nil
+}
+
+type fakeTxBeginner struct {
+	txs []*fakeTx
+}
+
+func (b *fakeTxBeginner) Begin(context.Context) (pgx.Tx, error) {
+	tx := &fakeTx{}
+	b.txs = append(b.txs, tx)
+	return tx, nil
+}
+
+// serviceDeps — общие для тестов сервисов заглушки пула и репозиториев.
+type serviceDeps struct {
+	beginner    *fakeTxBeginner
+	jobRepo     *mocks.MockJobRepositoryInterface
+	orderRepo   *mocks.MockOrderRepositoryInterface
+	userRepo    *mocks.MockUserRepositoryInterface
+	entryRepo   *mocks.MockBalanceEntryRepositoryInterface
+	sessionRepo *mocks.MockSessionRepositoryInterface
+}
+
+func newServiceDeps(t *testing.T) *serviceDeps {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	return &serviceDeps{
+		beginner:    &fakeTxBeginner{},
+		jobRepo:     mocks.NewMockJobRepositoryInterface(ctrl),
+		orderRepo:   mocks.NewMockOrderRepositoryInterface(ctrl),
+		userRepo:    mocks.NewMockUserRepositoryInterface(ctrl),
+		entryRepo:   mocks.NewMockBalanceEntryRepositoryInterface(ctrl),
+		sessionRepo: mocks.NewMockSessionRepositoryInterface(ctrl),
+	}
+}
+
+// serveOrder отдаёт из репозитория копию заказа тестового задания.
+// Тест меняет заказ через возвращённый указатель.
+func (d *serviceDeps) serveOrder() *entities.Order {
+	order := &entities.Order{
+		ID:       testOrderID,
+		OrderID:  testOrderNumber,
+		UserID:   7,
+		StatusID: entities.StatusNew,
+	}
+	serve := func(context.Context, pgx.Tx, int64) (*entities.Order, error) {
+		served := *order
+		return &served, nil
+	}
+	d.orderRepo.EXPECT().GetByID(gomock.Any(), gomock.Any(), int64(testOrderID)).DoAndReturn(serve).AnyTimes()
+	d.orderRepo.EXPECT().LockByID(gomock.Any(), gomock.Any(), int64(testOrderID)).DoAndReturn(serve).AnyTimes()
+	return order
+}
+
+const (
+	testJobID       = 11
+	testOrderID     = 22
+	testOrderNumber = 12345678903
+	testMaxAttempts = 3
+)
+
+const testWorkerID = "worker-1"
+
+var testAccrualConfig = &config.Config{
+	AgentWorkerID:    testWorkerID,
+	AgentMaxAttempts: testMaxAttempts,
+	AgentBackoffBase: time.Second,
+	AgentBackoffMax:  time.Minute,
+}
+
+func TestAccrualServiceSendOrder_FailureSchedulesRetry(t *testing.T) {
+	tests := []struct {
+		expectedErr      error
+		name             string
+		step             fake.Step
+		expectedCategory accrual.ErrorCategory
+		minDelay         time.Duration
+		maxDelay         time.Duration
+		attempts         int
+		expectedAttempts int
+	}{
+		{
+			name:             "204 counts as attempt",
+			step:             fake.NoContent(),
+			expectedErr:      accrual.ErrNoContent,
+			expectedCategory: accrual.CategoryNoContent,
+			attempts:         0,
+			expectedAttempts: 1,
+			minDelay:         500 * time.Millisecond,
+			maxDelay:         time.Second,
+		},
+		{
+			name:             "500 backs off exponentially",
+			step:             fake.ServerError(),
+			expectedErr:      accrual.ErrServerError,
+			expectedCategory: accrual.CategoryServerError,
+			attempts:         1,
+			expectedAttempts: 2,
+			minDelay:         time.Second,
+			maxDelay:         2 * time.Second,
+		},
+		{
+			name:             "429 waits Retry-After without counting attempt",
+			step:             fake.TooManyRequests(5),
+			expectedCategory: accrual.CategoryRateLimited,
+			attempts:         2,
+			expectedAttempts: 2,
+			minDelay:         4 * time.Second,
+			maxDelay:         5 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newServiceDeps(t)
+			d.serveOrder()
+			service := NewAccrualService(d.beginner, d.jobRepo, d.orderRepo, d.userRepo, d.entryRepo,
+				fake.New().Script(testOrderNumber, tt.step), testAccrualConfig, zap.NewNop().Sugar())
+			job := &entities.Job{ID: testJobID, OrderID: testOrderID, Attempts: tt.attempts}
+
+			var scheduled *entities.Job
+			d.jobRepo.EXPECT().ScheduleRetry(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ pgx.Tx, failed *entities.Job, _ string) error {
+					scheduled = failed
+					return nil
+				})
+
+			start := time.Now()
+			err := service.SendOrder(context.Background(), job)
+
+			require.Error(t, err)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				var tooManyErr *accrual.TooManyRequestsWithRetryError
+				assert.ErrorAs(t, err, &tooManyErr)
+			}
+			require.NotNil(t, scheduled)
+			assert.Equal(t, int64(testJobID), scheduled.ID)
+			assert.Equal(t, tt.expectedAttempts, scheduled.Attempts)
+			require.NotNil(t, scheduled.LastErrorCategory)
+			assert.Equal(t, string(tt.expectedCategory), *scheduled.LastErrorCategory)
+			require.NotNil(t, scheduled.LastError)
+			assert.Equal(t, err.Error(), *scheduled.LastError)
+			require.NotNil(t, scheduled.NextAttemptAt)
+			assert.WithinRange(t, *scheduled.NextAttemptAt, start.Add(tt.minDelay), time.Now().Add(tt.maxDelay))
+			assert.Equal(t, tt.attempts, job.Attempts, "original job must not be mutated")
+
+			// запрос к системе начислений выполняется вне транзакции
+			require.Len(t, d.beginner.txs, 1)
+			assert.True(t, d.beginner.txs[0].committed)
+		})
+	}
+}
+
+func TestAccrualServiceSendOrder_NetworkFailure(t *testing.T) {
+	d := newServiceDeps(t)
+	d.serveOrder()
+	service := NewAccrualService(d.beginner, d.jobRepo, d.orderRepo, d.userRepo, d.entryRepo,
+		fake.New().Script(testOrderNumber, fake.NetworkError()), testAccrualConfig, zap.NewNop().Sugar())
+
+	var scheduled *entities.Job
+	d.jobRepo.EXPECT().ScheduleRetry(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ pgx.Tx, failed *entities.Job, _ string) error {
+			scheduled = failed
+			return nil
+		})
+
+	err := service.SendOrder(context.Background(), &entities.Job{ID: testJobID, OrderID: testOrderID})
+
+	assert.ErrorIs(t, err, accrual.ErrNetwork)
+	require.NotNil(t, scheduled)
+	assert.Equal(t, 1, scheduled.Attempts)
+	assert.Equal(t, string(accrual.CategoryNetwork), *scheduled.LastErrorCategory)
+	assert.True(t, d.beginner.txs[0].committed)
+}
+
+func TestAccrualServiceSendOrder_OpenCircuitReschedulesWithoutAttempt(t *testing.T) {
+	d := newServiceDeps(t)
+	d.serveOrder()
+	const downOrderNumber = 79927398713
+	client := fake.New().
+		Script(testOrderNumber, fake.Processed(money.FromMinorUnits(100))).
+		Script(downOrderNumber, fake.ServerError())
+	breaker := accrual.NewBreaker(accrual.BreakerConfig{
+		FailureThreshold: 1,
+		OpenTimeout:      time.Minute,
+		HalfOpenRequests: 1,
+	})
+	guarded := accrual.WithBreaker(client, breaker)
+	_, err := guarded.GetOrder(context.Background(), downOrderNumber)
+	require.ErrorIs(t, err, accrual.ErrServerError)
+	job := &entities.Job{ID: testJobID, OrderID: testOrderID, Attempts: testMaxAttempts - 1}
+
+	service := NewAccrualService(d.beginner, d.jobRepo, d.orderRepo, d.userRepo, d.entryRepo,
+		guarded, testAccrualConfig, zap.NewNop().Sugar())
+
+	var scheduled *entities.Job
+	d.jobRepo.EXPECT().ScheduleRetry(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ pgx.Tx, failed *entities.Job, _ string) error {
+			scheduled = failed
+			return nil
+		})
+
+	err = service.SendOrder(context.Background(), job)
+
+	var openErr *accrual.CircuitOpenError
+	require.ErrorAs(t, err, &openErr)
+	require.NotNil(t, scheduled)
+	// попытка не засчитана, иначе задание ушло бы в dead-letter
+	assert.Equal(t, testMaxAttempts-1, scheduled.Attempts)
+	assert.Equal(t, openErr.Until, *scheduled.NextAttemptAt)
+	assert.Equal(t, string(accrual.CategoryCircuitOpen), *scheduled.LastErrorCategory)
+	assert.Zero(t, client.Calls(testOrderNumber))
+	assert.True(t, d.beginner.txs[0].committed)
+}
+
+func TestAccrualServiceSendOrder_DeadLetterAfterMaxAttempts(t *testing.T) {
+	d := newServiceDeps(t)
+	d.serveOrder()
+	service := NewAccrualService(d.beginner, d.jobRepo, d.orderRepo, d.userRepo, d.entryRepo,
+		fake.New().Script(testOrderNumber, fake.NoContent()), testAccrualConfig, zap.NewNop().Sugar())
+	job := &entities.Job{ID: testJobID, OrderID: testOrderID, Attempts: testMaxAttempts - 1}
+
+	d.jobRepo.EXPECT().MarkJobDead(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ pgx.Tx, failed *entities.Job, _ string) error {
+			assert.Equal(t, testMaxAttempts, failed.Attempts)
+			assert.Equal(t, string(accrual.CategoryNoContent), *failed.LastErrorCategory)
+			return nil
+		})
+	d.orderRepo.EXPECT().UpdateOrder(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ pgx.Tx, order *entities.Order) error {
+			assert.Equal(t, int16(entities.StatusInvalid), order.StatusID)
+			assert.False(t, order.Accrual.Valid)
+			return nil
+		})
+	failedBefore := testutil.ToFloat64(metrics.JobsFailed.WithLabelValues(string(accrual.CategoryNoContent)))
+	deadBefore := testutil.ToFloat64(metrics.JobsDeadLettered)
+
+	err := service.SendOrder(context.Background(), job)
+
+	assert.ErrorIs(t, err, accrual.ErrNoContent)
+	assert.True(t, d.beginner.txs[0].committed)
+	assert.Equal(t, failedBefore+1,
+		testutil.ToFloat64(metrics.JobsFailed.WithLabelValues(string(accrual.CategoryNoContent))))
+	assert.Equal(t, deadBefore+1, testutil.ToFloat64(metrics.JobsDeadLettered))
+}
+
+func TestAccrualServiceSendOrder_BookkeepingFailureKeepsOriginalError(t *testing.T) {
+	d := newServiceDeps(t)
+	d.serveOrder()
+	service := NewAccrualService(d.beginner, d.jobRepo, d.orderRepo, d.userRepo, d.entryRepo,
+		fake.New().Script(testOrderNumber, fake.ServerError()), testAccrualConfig, zap.NewNop().Sugar())
+	dbErr := errors.New("connection reset")
+	d.jobRepo.EXPECT().ScheduleRetry(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(dbErr)
+
+	err := service.SendOrder(context.Background(), &entities.Job{ID: testJobID, OrderID: testOrderID})
+
+	assert.ErrorIs(t, err, accrual.ErrServerError)
+	assert.ErrorIs(t, err, dbErr)
+	require.Len(t, d.beginner.txs, 1)
+	assert.False(t, d.beginner.txs[0].committed)
+	assert.True(t, d.beginner.txs[0].rolledBack)
+}
+
+// callbackDuringRequestClient имитирует уведомление, которое завершило расчёт заказа, пока шёл запрос.
+type callbackDuringRequestClient struct {
+	accrual.Client
+	order   *entities.Order
+	accrual money.Money
+}
+
+func (c *callbackDuringRequestClient) GetOrder(ctx context.Context, number int) (*accrual.OrderResponse, error) {
+	c.order.StatusID = entities.StatusProcessed
+	c.order.Accrual = money.NullMoney{Money: c.accrual, Valid: true}
+	return c.Client.GetOrder(ctx, number)
+}
+
+func TestAccrualServiceSendOrder_DeadLetterKeepsOrderProcessedMeanwhile(t *testing.T) {
+	d := newServiceDeps(t)
+	order := d.serveOrder()
+	client := &callbackDuringRequestClient{
+		Client:  fake.New().Script(testOrderNumber, fake.NoContent()),
+		order:   order,
+		accrual: money.FromMinorUnits(500),
+	}
+	service := NewAccrualService(d.beginner, d.jobRepo, d.orderRepo, d.userRepo, d.entryRepo,
+		client, testAccrualConfig, zap.NewNop().Sugar())
+	job := &entities.Job{ID: testJobID, OrderID: testOrderID, Attempts: testMaxAttempts - 1}
+
+	// заказ перечитывается под блокировкой, поэтому INVALID не затирает начисление
+	d.jobRepo.EXPECT().MarkJobDead(gomock.Any(), gomock.Any(), gomock.Any(), testWorkerID).Return(nil)
+
+	err := service.SendOrder(context.Background(), job)
+
+	assert.ErrorIs(t, err, accrual.ErrNoContent)
+	assert.True(t, d.beginner.txs[0].committed)
+}
+
+func TestAccrualServiceSendOrder_LostLeaseRollsBackBookkeeping(t *testing.T) {
+	d := newServiceDeps(t)
+	d.serveOrder()
+	service := NewAccrualService(d.beginner, d.jobRepo, d.orderRepo, d.userRepo, d.entryRepo,
+		fake.New().Script(testOrderNumber, fake.NoContent()), testAccrualConfig, zap.NewNop().Sugar())
+	job := &entities.Job{ID: testJobID, OrderID: testOrderID, Attempts: testMaxAttempts - 1}
+
+	// пока шёл запрос, аренда истекла и задание перешло к другому воркеру
+	d.jobRepo.EXPECT().MarkJobDead(gomock.Any(), gomock.Any(), gomock.Any(), testWorkerID).
+		Return(apperrors.ErrJobLeaseLost)
+	deadBefore := testutil.ToFloat64(metrics.JobsDeadLettered)
+
+	err := service.SendOrder(context.Background(), job)
+
+	assert.ErrorIs(t, err, accrual.ErrNoContent)
+	assert.ErrorIs(t, err, apperrors.ErrJobLeaseLost)
+	require.Len(t, d.beginner.txs, 1)
+	assert.True(t, d.beginner.txs[0].rolledBack)
+	assert.Equal(t, deadBefore, testutil.ToFloat64(metrics.JobsDeadLettered))
+}
+
+func TestAccrualServiceSendOrder_CancelledContextSkipsBookkeeping(t *testing.T) {
+	d := newServiceDeps(t)
+	d.serveOrder()
+	client := fake.New().Script(testOrderNumber, fake.Status("PROCESSED").After(time.Hour))
+	service := NewAccrualService(d.beginner, d.jobRepo, d.orderRepo, d.userRepo, d.entryRepo,
+		client, testAccrualConfig, zap.NewNop().Sugar())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := service.SendOrder(ctx, &entities.Job{ID: testJobID, OrderID: testOrderID})
+
+	assert.Error(t, err)
+	assert.Empty(t, d.beginner.txs)
+}
+
+func TestAccrualServiceSendOrder_ProcessedOrderAccruesPoints(t *testing.T) {
+	accrued := money.FromMinorUnits(72998)
+	d := newServiceDeps(t)
+	d.serveOrder()
+	service := NewAccrualService(d.beginner, d.jobRepo, d.orderRepo, d.userRepo, d.entryRepo,
+		fake.New().Script(testOrderNumber, fake.Processed(accrued)), testAccrualConfig, zap.NewNop().Sugar())
+
+	d.orderRepo.EXPECT().UpdateOrder(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ pgx.Tx, order *entities.Order) error {
+			assert.Equal(t, int16(entities.StatusProcessed), order.StatusID)
+			assert.Equal(t, money.NullMoney{Money: accrued, Valid: true}, order.Accrual)
+			return nil
+		})
+	d.userRepo.EXPECT().LockBalanceByUserID(gomock.Any(), gomock.Any(), int64(7)).Return(money.Money(0), nil)
+	d.entryRepo.EXPECT().Store(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ pgx.Tx, entry *entities.BalanceEntry) error {
+			assert.Equal(t, accrued, entry.Amount)
+			assert.Equal(t, int64(testOrderID), entry.OrderID.Int64)
+			return nil
+		})
+	d.userRepo.EXPECT().AddBalanceByUserID(gomock.Any(), gomock.Any(), accrued, int64(7)).Return(nil)
+	d.jobRepo.EXPECT().DeleteJobByID(gomock.Any(), gomock.Any(), int64(testJobID)).Return(nil)
+	accruedBefore := testutil.ToFloat64(metrics.PointsAccrued)
+	completedBefore := testutil.ToFloat64(metrics.JobsCompleted)
+
+	err := service.SendOrder(context.Background(), &entities.Job{ID: testJobID, OrderID: testOrderID})
+
+	require.NoError(t, err)
+	require.Len(t, d.beginner.txs, 1)
+	assert.True(t, d.beginner.txs[0].committed)
+	assert.InDelta(t, accruedBefore+accrued.Float64(), testutil.ToFloat64(metrics.PointsAccrued), 1e-9)
+	assert.Equal(t, completedBefore+1, testutil.ToFloat64(metrics.JobsCompleted))
+}
+
+// cancelAfterResponseClient останавливает воркер сразу после ответа системы начислений
+// и запоминает, сколько транзакций было открыто к моменту запроса.
+type cancelAfterResponseClient struct {
+	accrual.Client
+	beginner     *fakeTxBeginner
+	cancel       context.CancelFunc
+	txsOnRequest int
+}
+
+func (c *cancelAfterResponseClient) GetOrder(ctx context.Context, number int) (*accrual.OrderResponse, error) {
+	c.txsOnRequest = len(c.beginner.txs)
+	defer c.cancel()
+	return c.Client.GetOrder(ctx, number)
+}
+
+func TestAccrualServiceSendOrder_AppliesResponseAfterCancel(t *testing.T) {
+	d := newServiceDeps(t)
+	d.serveOrder()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &cancelAfterResponseClient{
+		Client:   fake.New().Script(testOrderNumber, fake.Status("INVALID")),
+		beginner: d.beginner,
+		cancel:   cancel,
+	}
+	service := NewAccrualService(d.beginner, d.jobRepo, d.orderRepo, d.userRepo, d.entryRepo,
+		client, testAccrualConfig, zap.NewNop().Sugar())
+
+	d.orderRepo.EXPECT().UpdateOrder(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, _ pgx.Tx, order *entities.Order) error {
+			assert.NoError(t, ctx.Err())
+			assert.Equal(t, int16(entities.StatusInvalid), order.StatusID)
+			return nil
+		})
+	d.jobRepo.EXPECT().DeleteJobByID(gomock.Any(), gomock.Any(), int64(testJobID)).Return(nil)
+
+	err := service.SendOrder(ctx, &entities.Job{ID: testJobID, OrderID: testOrderID})
+
+	require.NoError(t, err)
+	assert.Zero(t, client.txsOnRequest)
+	require.Len(t, d.beginner.txs, 1)
+	assert.True(t, d.beginner.txs[0].committed)
+}
+
+func TestAccrualServiceSendOrder_UnknownStatusIsRejected(t *testing.T) {
+	d := newServiceDeps(t)
+	d.serveOrder()
+	service := NewAccrualService(d.beginner, d.jobRepo, d.orderRepo, d.userRepo, d.entryRepo,
+		fake.New().Script(testOrderNumber, fake.Status("CANCELLED")), testAccrualConfig, zap.NewNop().Sugar())
+
+	var scheduled *entities.Job
+	d.jobRepo.EXPECT().ScheduleRetry(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ pgx.Tx, failed *entities.Job, _ string) error {
+			scheduled = failed
+			return nil
+		})
+
+	err := service.SendOrder(context.Background(), &entities.Job{ID: testJobID, OrderID: testOrderID})
+
+	var unknownErr *entities.UnknownStatusError
+	require.ErrorAs(t, err, &unknownErr)
+	assert.Equal(t, "CANCELLED", unknownErr.Status)
+	require.NotNil(t, scheduled)
+	assert.Equal(t, string(accrual.CategoryUnexpected), *scheduled.LastErrorCategory)
+}
+
+func TestAccrualServiceSendOrder_PollsUntilTerminalStatus(t *testing.T) {
+	accrued := money.FromMinorUnits(50000)
+	responses := []fake.Step{
+		fake.Status("REGISTERED"),
+		fake.Status("PROCESSING"),
+		fake.Status("PROCESSING"),
+		fake.Processed(accrued),
+	}
+	d := newServiceDeps(t)
+	order := d.serveOrder()
+	service := NewAccrualService(d.beginner, d.jobRepo, d.orderRepo, d.userRepo, d.entryRepo,
+		fake.New().Script(testOrderNumber, responses...), testAccrualConfig, zap.NewNop().Sugar())
+	d.orderRepo.EXPECT().UpdateOrder(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ pgx.Tx, updated *entities.Order) error {
+			*order = *updated
+			return nil
+		}).Times(len(responses))
+
+	job := entities.Job{ID: testJobID, OrderID: testOrderID}
+	var delays []time.Duration
+	d.jobRepo.EXPECT().ScheduleNextPoll(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ pgx.Tx, next *entities.Job, _ string) error {
+			assert.Equal(t, job.Polls+1, next.Polls)
+			delays = append(delays, time.Until(*next.NextAttemptAt))
+			job = *next
+			return nil
+		}).Times(len(responses) - 1)
+
+	for range len(responses) - 1 {
+		require.NoError(t, service.SendOrder(context.Background(), &job))
+		assert.Equal(t, int16(entities.StatusProcessing), order.StatusID)
+		assert.False(t, order.Accrual.Valid)
+	}
+	// верхняя граница задержки растёт: 1s, 2s, 4s
+	require.Len(t, delays, 3)
+	assert.LessOrEqual(t, delays[0], time.Second)
+	assert.Greater(t, delays[2], time.Second)
+
+	d.userRepo.EXPECT().LockBalanceByUserID(gomock.Any(), gomock.Any(), int64(7)).Return(money.Money(0), nil)
+	d.entryRepo.EXPECT().Store(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	d.userRepo.EXPECT().AddBalanceByUserID(gomock.Any(), gomock.Any(), accrued, int64(7)).Return(nil)
+	d.jobRepo.EXPECT().DeleteJobByID(gomock.Any(), gomock.Any(), int64(testJobID)).Return(nil)
+
+	require.NoError(t, service.SendOrder(context.Background(), &job))
+	assert.Equal(t, int16(entities.StatusProcessed), order.StatusID)
+	assert.Equal(t, money.NullMoney{Money: accrued, Valid: true}, order.Accrual)
+	for _, tx := range d.beginner.txs {
+		assert.True(t, tx.committed)
+	}
+}
+
+func TestAccrualServiceSendOrder_ReverificationCorrectsAccrual(t *testing.T) {
+	previous := money.FromMinorUnits(50000)
+	tests := []struct {
+		name       string
+		accrual    money.Money
+		correction money.Money
+	}{
+		{
+			name:       "accrual increased",
+			accrual:    money.FromMinorUnits(72998),
+			correction: money.FromMinorUnits(22998),
+		},
+		{
+			name:       "accrual decreased",
+			accrual:    money.FromMinorUnits(20000),
+			correction: money.FromMinorUnits(-30000),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newServiceDeps(t)
+			order := d.serveOrder()
+			service := NewAccrualService(d.beginner, d.jobRepo, d.orderRepo, d.userRepo, d.entryRepo,
+				fake.New().Script(testOrderNumber, fake.Processed(tt.accrual)), testAccrualConfig, zap.NewNop().Sugar())
+			order.StatusID = entities.StatusProcessed
+			order.Accrual = money.NullMoney{Money: previous, Valid: true}
+
+			d.orderRepo.EXPECT().UpdateOrder(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ pgx.Tx, order *entities.Order) error {
+					assert.Equal(t, money.NullMoney{Money: tt.accrual, Valid: true}, order.Accrual)
+					assert.Equal(t, money.NullMoney{Money: previous, Valid: true}, order.PreviousAccrual)
+					assert.NotNil(t, order.AccrualCorrectedAt)
+					return nil
+				})
+			// уменьшение сверяется с балансом до проводки
+			d.userRepo.EXPECT().LockBalanceByUserID(gomock.Any(), gomock.Any(), int64(7)).Return(previous, nil).MinTimes(1)
+			d.entryRepo.EXPECT().Store(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ pgx.Tx, entry *entities.BalanceEntry) error {
+					assert.Equal(t, int16(entities.EntryTypeAdjustment), entry.TypeID)
+					assert.Equal(t, tt.correction, entry.Amount)
+					assert.Equal(t, int64(testOrderID), entry.OrderID.Int64)
+					assert.Contains(t, entry.Reason.String, "corrected")
+					return nil
+				})
+			d.userRepo.EXPECT().AddBalanceByUserID(gomock.Any(), gomock.Any(), tt.correction, int64(7)).Return(nil)
+			d.jobRepo.EXPECT().DeleteJobByID(gomock.Any(), gomock.Any(), int64(testJobID)).Return(nil)
+
+			err := service.SendOrder(context.Background(), &entities.Job{ID: testJobID, OrderID: testOrderID, Reverify: true})
+
+			require.NoError(t, err)
+			assert.True(t, d.beginner.txs[0].committed)
+		})
+	}
+}
+
+func TestAccrualServiceSendOrder_ReverificationWithoutChange(t *testing.T) {
+	accrued := money.FromMinorUnits(50000)
+	d := newServiceDeps(t)
+	order := d.serveOrder()
+	service := NewAccrualService(d.beginner, d.jobRepo, d.orderRepo, d.userRepo, d.entryRepo,
+		fake.New().Script(testOrderNumber, fake.Processed(accrued)), testAccrualConfig, zap.NewNop().Sugar())
+	order.StatusID = entities.StatusProcessed
+	order.Accrual = money.NullMoney{Money: accrued, Valid: true}
+
+	d.orderRepo.EXPECT().UpdateOrder(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ pgx.Tx, order *entities.Order) error {
+			assert.False(t, order.PreviousAccrual.Valid)
+			assert.Nil(t, order.AccrualCorrectedAt)
+			return nil
+		})
+	d.jobRepo.EXPECT().DeleteJobByID(gomock.Any(), gomock.Any(), int64(testJobID)).Return(nil)
+
+	err := service.SendOrder(context.Background(), &entities.Job{ID: testJobID, OrderID: testOrderID, Reverify: true})
+
+	require.NoError(t, err)
+	assert.True(t, d.beginner.txs[0].committed)
+}
+
+func TestAccrualServiceSendOrder_StaleResponseKeepsProcessedAccrual(t *testing.T) {
+	previous := money.FromMinorUnits(50000)
+	d := newServiceDeps(t)
+	order := d.serveOrder()
+	service := NewAccrualService(d.beginner, d.jobRepo, d.orderRepo, d.userRepo, d.entryRepo,
+		fake.New().Script(testOrderNumber, fake.Processed(money.FromMinorUnits(100))), testAccrualConfig,
+		zap.NewNop().Sugar())
+	order.StatusID = entities.StatusProcessed
+	order.Accrual = money.NullMoney{Money: previous, Valid: true}
+
+	// задание не из повторной проверки: ответ устарел, заказ и баланс не меняются
+	d.jobRepo.EXPECT().DeleteJobByID(gomock.Any(), gomock.Any(), int64(testJobID)).Return(nil)
+
+	err := service.SendOrder(context.Background(), &entities.Job{ID: testJobID, OrderID: testOrderID})
+
+	require.NoError(t, err)
+	assert.Equal(t, money.NullMoney{Money: previous, Valid: true}, order.Accrual)
+	assert.True(t, d.beginner.txs[0].committed)
+}
+
+func TestAccrualServiceSendOrder_CorrectionExceedingBalanceGoesToDeadLetter(t *testing.T) {
+	previous := money.FromMinorUnits(50000)
+	d := newServiceDeps(t)
+	order := d.serveOrder()
+	service := NewAccrualService(d.beginner, d.jobRepo, d.orderRepo, d.userRepo, d.entryRepo,
+		fake.New().Script(testOrderNumber, fake.Processed(money.FromMinorUnits(20000))), testAccrualConfig,
+		zap.NewNop().Sugar())
+	order.StatusID = entities.StatusProcessed
+	order.Accrual = money.NullMoney{Money: previous, Valid: true}
+
+	// баллы уже потрачены: списать 300 из оставшихся 100 нельзя
+	d.userRepo.EXPECT().LockBalanceByUserID(gomock.Any(), gomock.Any(), int64(7)).
+		Return(money.FromMinorUnits(10000), nil)
+	d.jobRepo.EXPECT().MarkJobDead(gomock.Any(), gomock.Any(), gomock.Any(), testWorkerID).DoAndReturn(
+		func(_ context.Context, _ pgx.Tx, job *entities.Job, _ string) error {
+			assert.Equal(t, int64(testJobID), job.ID)
+			require.NotNil(t, job.LastErrorCategory)
+			assert.Equal(t, categoryCorrectionExceedsBalance, *job.LastErrorCategory)
+			require.NotNil(t, job.LastError)
+			assert.Contains(t, *job.LastError, "exceeds balance")
+			return nil
+		})
+
+	err := service.SendOrder(context.Background(), &entities.Job{ID: testJobID, OrderID: testOrderID, Reverify: true})
+
+	require.NoError(t, err)
+	assert.Equal(t, money.NullMoney{Money: previous, Valid: true}, order.Accrual)
+	assert.True(t, d.beginner.txs[0].committed)
+}
@@ -0,0 +1,21 @@
+package services
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// retryDelay возвращает задержку перед попыткой attempt (с единицы): base * 2^(attempt-1), но не больше maxDelay.
+// Половина задержки случайна, чтобы задания, упавшие одновременно, не повторялись одной пачкой.
+func retryDelay(attempt int, base, maxDelay time.Duration) time.Duration {
+	delay := maxDelay
+	if attempt < 1 {
+		attempt = 1
+	}
+	// сдвиг больше 62 переполнит Duration, к этому моменту задержка давно упёрлась в maxDelay
+	if shift := attempt - 1; shift < 62 && base<<shift > 0 && base<<shift < maxDelay {
+		delay = base << shift
+	}
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
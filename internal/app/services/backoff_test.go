@@ -0,0 +1,37 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryDelay(t *testing.T) {
+	const (
+		base     = 10 * time.Second
+		maxDelay = time.Minute
+	)
+	tests := []struct {
+		name    string
+		attempt int
+		ceiling time.Duration
+	}{
+		{name: "first attempt", attempt: 1, ceiling: 10 * time.Second},
+		{name: "second attempt", attempt: 2, ceiling: 20 * time.Second},
+		{name: "third attempt", attempt: 3, ceiling: 40 * time.Second},
+		{name: "capped", attempt: 4, ceiling: maxDelay},
+		{name: "no overflow", attempt: 200, ceiling: maxDelay},
+		{name: "zero attempt", attempt: 0, ceiling: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 100 {
+				delay := retryDelay(tt.attempt, base, maxDelay)
+				assert.GreaterOrEqual(t, delay, tt.ceiling/2)
+				assert.LessOrEqual(t, delay, tt.ceiling)
+			}
+		})
+	}
+}
@@ -2,12 +2,14 @@ package services
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"gophermart/internal/app/apperrors"
 	"gophermart/internal/app/dto"
 	"gophermart/internal/app/entities"
 	"gophermart/internal/app/repositories"
-	"math"
+	"gophermart/internal/metrics"
+	"gophermart/internal/tracing"
 	"strconv"
 	"time"
 
@@ -19,14 +21,15 @@ type BalanceService interface {
 	GetBalance(ctx context.Context, userID int) (dto.BalanceResponseBody, error)
 	GetWithdrawals(ctx context.Context, userID int) ([]dto.WithdrawalsResponseBody, error)
 	Withdraw(ctx context.Context, userID int, req dto.WithdrawBody) error
+	Reconcile(ctx context.Context) ([]entities.BalanceMismatch, error)
 }
 
 type balanceService struct {
-	Pool               *pgxpool.Pool
-	UserRepository     repositories.UserRepositoryInterface
-	OrderRepository    repositories.OrderRepositoryInterface
-	WithdrawRepository repositories.WithdrawRepositoryInterface
-	roundingFactor     float64
+	Pool                   *pgxpool.Pool
+	UserRepository         repositories.UserRepositoryInterface
+	OrderRepository        repositories.OrderRepositoryInterface
+	WithdrawRepository     repositories.WithdrawRepositoryInterface
+	BalanceEntryRepository repositories.BalanceEntryRepositoryInterface
 }
 
 func NewBalanceService(
@@ -34,48 +37,51 @@ func NewBalanceService(
 	userRepository repositories.UserRepositoryInterface,
 	orderRepository repositories.OrderRepositoryInterface,
 	withdrawRepository repositories.WithdrawRepositoryInterface,
+	balanceEntryRepository repositories.BalanceEntryRepositoryInterface,
 ) BalanceService {
-	const roundingFactor = 100
 	return &balanceService{
-		Pool:               db,
-		UserRepository:     userRepository,
-		OrderRepository:    orderRepository,
-		WithdrawRepository: withdrawRepository,
-		roundingFactor:     roundingFactor,
+		Pool:                   db,
+		UserRepository:         userRepository,
+		OrderRepository:        orderRepository,
+		WithdrawRepository:     withdrawRepository,
+		BalanceEntryRepository: balanceEntryRepository,
 	}
 }
 
-func (o *balanceService) GetBalance(ctx context.Context, userID int) (dto.BalanceResponseBody, error) {
+func (o *balanceService) GetBalance(ctx context.Context, userID int) (_ dto.BalanceResponseBody, err error) {
+	ctx, span := tracer.Start(ctx, "BalanceService.GetBalance")
+	defer func() { tracing.End(span, err) }()
+
 	var balanceResponse dto.BalanceResponseBody
 	current, err := o.UserRepository.GetBalanceByUserID(ctx, nil, int64(userID))
 	if err != nil {
 		return balanceResponse, fmt.Errorf("failed GetBalance: %w", err)
 	}
-	withdrawn, err := o.WithdrawRepository.GetTotalWithdrawByUserID(ctx, userID)
+	withdrawn, err := o.BalanceEntryRepository.GetWithdrawnByUserID(ctx, userID)
 	if err != nil {
-		return balanceResponse, fmt.Errorf("failed GetTotalWithdrawByUserID: %w", err)
+		return balanceResponse, fmt.Errorf("failed GetWithdrawnByUserID: %w", err)
 	}
-	roundedAmount := math.Round(withdrawn*o.roundingFactor) / o.roundingFactor
-
 	balanceResponse = dto.BalanceResponseBody{
 		Current:   current,
-		Withdrawn: roundedAmount,
+		Withdrawn: withdrawn,
 	}
 
 	return balanceResponse, nil
 }
 
-func (o *balanceService) GetWithdrawals(ctx context.Context, userID int) ([]dto.WithdrawalsResponseBody, error) {
+func (o *balanceService) GetWithdrawals(ctx context.Context, userID int) (_ []dto.WithdrawalsResponseBody, err error) {
+	ctx, span := tracer.Start(ctx, "BalanceService.GetWithdrawals")
+	defer func() { tracing.End(span, err) }()
+
 	withdraws, err := o.WithdrawRepository.GetByUserID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed GetWithdrawals: %w", err)
 	}
 	response := make([]dto.WithdrawalsResponseBody, 0, len(withdraws))
 	for _, withdraw := range withdraws {
-		roundedAmount := math.Round(withdraw.Withdraw*o.roundingFactor) / o.roundingFactor
 		response = append(response, dto.WithdrawalsResponseBody{
 			Number:     strconv.Itoa(withdraw.OrderID),
-			Withdrawaw: roundedAmount,
+			Withdrawaw: withdraw.Withdraw,
 			CreatedAt:  withdraw.CreatedAt.Format(time.RFC3339),
 		})
 	}
@@ -83,7 +89,13 @@ func (o *balanceService) GetWithdrawals(ctx context.Context, userID int) ([]dto.
 	return response, nil
 }
 
-func (o *balanceService) Withdraw(ctx context.Context, userID int, req dto.WithdrawBody) error {
+func (o *balanceService) Withdraw(ctx context.Context, userID int, req dto.WithdrawBody) (err error) {
+	ctx, span := tracer.Start(ctx, "BalanceService.Withdraw")
+	defer func() { tracing.End(span, err) }()
+
+	if req.Sum <= 0 {
+		return apperrors.ErrInvalidWithdrawSum
+	}
 	tx, err := o.Pool.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to start transaction: %w", err)
@@ -92,15 +104,13 @@ func (o *balanceService) Withdraw(ctx context.Context, userID int, req dto.Withd
 		_ = tx.Rollback(ctx)
 	}(tx, ctx)
 
-	current, err := o.UserRepository.GetBalanceByUserID(ctx, tx, int64(userID))
-	if err != nil {
-		return fmt.Errorf("failed GetBalanceByUserID: %w", err)
-	}
-	withdrawn, err := o.WithdrawRepository.GetTotalWithdrawByUserID(ctx, userID)
+	// блокируем строку пользователя до конца транзакции, чтобы параллельные списания
+	// не прошли проверку по одному и тому же остатку
+	current, err := o.UserRepository.LockBalanceByUserID(ctx, tx, int64(userID))
 	if err != nil {
-		return fmt.Errorf("failed GetTotalWithdrawByUserID: %w", err)
+		return fmt.Errorf("failed LockBalanceByUserID: %w", err)
 	}
-	if (current - withdrawn) < req.Sum {
+	if current < req.Sum {
 		return apperrors.ErrBalanceNotEnought
 	}
 	number, err := strconv.ParseInt(req.OrderNumber, 10, 64)
@@ -108,32 +118,27 @@ func (o *balanceService) Withdraw(ctx context.Context, userID int, req dto.Withd
 		return fmt.Errorf("failed convert OrderNumber to int : %w", err)
 	}
 
-	roundedAmount := math.Round(req.Sum*o.roundingFactor) / o.roundingFactor
 	withdrawOrder := entities.Withdraw{
 		UserID:   int64(userID),
 		OrderID:  int(number),
-		Withdraw: roundedAmount,
+		Withdraw: req.Sum,
 	}
 
-	err = o.WithdrawRepository.Save(ctx, tx, withdrawOrder)
+	withdrawOrder.ID, err = o.WithdrawRepository.Save(ctx, tx, withdrawOrder)
 	if err != nil {
 		return fmt.Errorf("failed to save Withdraw: %w", err)
 	}
 
 	if withdrawOrder.Withdraw > 0 {
-		var currentBalance float64
-		currentBalance, err = o.UserRepository.GetBalanceByUserID(ctx, tx, withdrawOrder.UserID)
-		if err != nil {
-			return fmt.Errorf("failed to get current balance for user %d: %w", withdrawOrder.UserID, err)
+		entry := entities.BalanceEntry{
+			UserID:     withdrawOrder.UserID,
+			TypeID:     entities.EntryTypeWithdrawal,
+			Amount:     -withdrawOrder.Withdraw,
+			WithdrawID: sql.NullInt64{Int64: int64(withdrawOrder.ID), Valid: true},
 		}
-		newBalance := currentBalance - withdrawOrder.Withdraw
-		err = o.UserRepository.UpdateBalanceByUserID(ctx, tx, newBalance, withdrawOrder.UserID)
+		err = postBalanceEntry(ctx, tx, o.BalanceEntryRepository, o.UserRepository, &entry)
 		if err != nil {
-			return fmt.Errorf(
-				"failed to update user balance for user %d: %w",
-				withdrawOrder.UserID,
-				err,
-			)
+			return fmt.Errorf("failed to withdraw points for user %d: %w", withdrawOrder.UserID, err)
 		}
 	}
 
@@ -141,5 +146,20 @@ func (o *balanceService) Withdraw(ctx context.Context, userID int, req dto.Withd
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
+	// Add паникует на отрицательном значении, поэтому счётчик растёт только на положительную сумму
+	if withdrawOrder.Withdraw > 0 {
+		metrics.PointsWithdrawn.Add(withdrawOrder.Withdraw.Float64())
+	}
 	return nil
 }
+
+func (o *balanceService) Reconcile(ctx context.Context) (_ []entities.BalanceMismatch, err error) {
+	ctx, span := tracer.Start(ctx, "BalanceService.Reconcile")
+	defer func() { tracing.End(span, err) }()
+
+	mismatches, err := o.BalanceEntryRepository.GetMismatches(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile balances: %w", err)
+	}
+	return mismatches, nil
+}
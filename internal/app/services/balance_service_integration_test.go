@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gophermart/internal/app/apperrors"
+	"gophermart/internal/app/dto"
+	"gophermart/internal/app/entities"
+	"gophermart/internal/app/money"
+	"gophermart/internal/app/repositories"
+	"gophermart/internal/store"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newIntegrationDB(t *testing.T) *store.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URI")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URI is not set")
+	}
+	db, err := store.NewDB(context.Background(), dsn)
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+	return db
+}
+
+func TestWithdraw_ConcurrentRequestsNeverOverdraw(t *testing.T) {
+	db := newIntegrationDB(t)
+	ctx := context.Background()
+
+	userRepo := repositories.NewUserRepository(db.Pool)
+	withdrawRepo := repositories.NewWithdrawRepository(db.Pool)
+	balanceEntryRepo := repositories.NewBalanceEntryRepository(db.Pool)
+	balanceService := NewBalanceService(db.Pool, userRepo, nil, withdrawRepo, balanceEntryRepo)
+
+	seed := time.Now().UnixNano()
+	user, err := userRepo.Store(ctx, entities.User{
+		Login:    fmt.Sprintf("concurrent-withdraw-%d", seed),
+		Password: "password",
+	})
+	require.NoError(t, err)
+
+	const (
+		requests        = 20
+		expectedSuccess = 10
+	)
+	sum := money.FromMinorUnits(100)
+
+	tx, err := db.Pool.Begin(ctx)
+	require.NoError(t, err)
+	err = postBalanceEntry(ctx, tx, balanceEntryRepo, userRepo, &entities.BalanceEntry{
+		UserID: int64(user.ID),
+		TypeID: entities.EntryTypeAdjustment,
+		Amount: sum * expectedSuccess,
+	})
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit(ctx))
+
+	var succeeded, rejected atomic.Int32
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			err := balanceService.Withdraw(ctx, user.ID, dto.WithdrawBody{
+				OrderNumber: strconv.FormatInt(seed%1_000_000_000_000+int64(i), 10),
+				Sum:         sum,
+			})
+			switch {
+			case err == nil:
+				succeeded.Add(1)
+			case errors.Is(err, apperrors.ErrBalanceNotEnought):
+				rejected.Add(1)
+			default:
+				t.Errorf("unexpected withdraw error: %v", err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, int32(expectedSuccess), succeeded.Load())
+	assert.Equal(t, int32(requests-expectedSuccess), rejected.Load())
+
+	balance, err := userRepo.GetBalanceByUserID(ctx, nil, int64(user.ID))
+	require.NoError(t, err)
+	assert.Equal(t, money.Money(0), balance)
+
+	withdrawn, err := balanceEntryRepo.GetWithdrawnByUserID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, sum*expectedSuccess, withdrawn)
+
+	mismatches, err := balanceService.Reconcile(ctx)
+	require.NoError(t, err)
+	for _, mismatch := range mismatches {
+		assert.NotEqual(t, int64(user.ID), mismatch.UserID)
+	}
+}
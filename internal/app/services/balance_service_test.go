@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"gophermart/internal/app/apperrors"
+	"gophermart/internal/app/dto"
+	"gophermart/internal/app/entities"
+	"gophermart/internal/app/money"
+	"gophermart/internal/app/repositories/mocks"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBalance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	userRepo := mocks.NewMockUserRepositoryInterface(ctrl)
+	balanceEntryRepo := mocks.NewMockBalanceEntryRepositoryInterface(ctrl)
+
+	userRepo.EXPECT().GetBalanceByUserID(gomock.Any(), nil, int64(1)).Return(money.FromMinorUnits(50050), nil)
+	balanceEntryRepo.EXPECT().GetWithdrawnByUserID(gomock.Any(), 1).Return(money.FromMinorUnits(4200), nil)
+
+	balanceService := NewBalanceService(nil, userRepo, nil, nil, balanceEntryRepo)
+
+	balance, err := balanceService.GetBalance(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, dto.BalanceResponseBody{Current: 50050, Withdrawn: 4200}, balance)
+}
+
+func TestWithdraw_RejectsNonPositiveSum(t *testing.T) {
+	tests := []struct {
+		name string
+		sum  money.Money
+	}{
+		{name: "zero", sum: 0},
+		{name: "negative", sum: money.FromMinorUnits(-100)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// транзакция не открывается, поэтому пул не нужен
+			balanceService := NewBalanceService(
+				nil,
+				mocks.NewMockUserRepositoryInterface(ctrl),
+				mocks.NewMockOrderRepositoryInterface(ctrl),
+				mocks.NewMockWithdrawRepositoryInterface(ctrl),
+				mocks.NewMockBalanceEntryRepositoryInterface(ctrl),
+			)
+
+			err := balanceService.Withdraw(context.Background(), 1, dto.WithdrawBody{OrderNumber: "2377225624", Sum: tt.sum})
+
+			require.ErrorIs(t, err, apperrors.ErrInvalidWithdrawSum)
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	balanceEntryRepo := mocks.NewMockBalanceEntryRepositoryInterface(ctrl)
+	mismatches := []entities.BalanceMismatch{
+		{UserID: 7, Cached: 10000, Ledger: 9000},
+	}
+	balanceEntryRepo.EXPECT().GetMismatches(gomock.Any()).Return(mismatches, nil)
+
+	balanceService := NewBalanceService(nil, nil, nil, nil, balanceEntryRepo)
+
+	result, err := balanceService.Reconcile(ctx)
+	require.NoError(t, err)
+	require.Equal(t, mismatches, result)
+}
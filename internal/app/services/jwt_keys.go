@@ -0,0 +1,188 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"gophermart/internal/app/dto"
+	"gophermart/internal/config"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+type verifyKey struct {
+	method jwt.SigningMethod
+	public crypto.PublicKey
+	jwk    dto.JWK
+}
+
+// KeySet — ключ подписи токенов доступа и ключи, которыми они ещё проверяются.
+// Открытые ключи идентифицируются kid — отпечатком по RFC 7638, поэтому kid не нужно настраивать:
+// при ротации новый ключ становится ключом подписи, а прежний остаётся в AuthVerifyKeyFiles,
+// пока не истекут выданные им токены.
+type KeySet struct {
+	signingMethod jwt.SigningMethod
+	signingKey    any
+	signingKID    string
+	// hmacSecret проверяет токены без kid; nil, если токены HS256 не принимаются.
+	hmacSecret []byte
+	verifyKeys map[string]verifyKey
+}
+
+// NewHMACKeySet подписывает и проверяет токены общим секретом.
+func NewHMACKeySet(secret string) *KeySet {
+	return &KeySet{
+		signingMethod: jwt.SigningMethodHS256,
+		signingKey:    []byte(secret),
+		hmacSecret:    []byte(secret),
+		verifyKeys:    map[string]verifyKey{},
+	}
+}
+
+// LoadKeySet читает ключи из конфигурации. При асимметричной подписи токены HS256 принимаются,
+// только если секрет задан явно, — это позволяет перейти на новый алгоритм без разлогина пользователей.
+func LoadKeySet(cfg *config.Config) (*KeySet, error) {
+	if cfg.AuthJwtAlgorithm == "" || cfg.AuthJwtAlgorithm == jwt.SigningMethodHS256.Alg() {
+		keys := NewHMACKeySet(cfg.AuthSecretKey)
+		if err := keys.addVerifyKeyFiles(cfg.AuthVerifyKeyFiles); err != nil {
+			return nil, err
+		}
+		return keys, nil
+	}
+
+	keys := &KeySet{verifyKeys: map[string]verifyKey{}}
+	if !cfg.AuthSecretGenerated {
+		keys.hmacSecret = []byte(cfg.AuthSecretKey)
+	}
+	content, err := os.ReadFile(cfg.AuthSigningKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	var public crypto.PublicKey
+	switch cfg.AuthJwtAlgorithm {
+	case jwt.SigningMethodRS256.Alg():
+		private, err := jwt.ParseRSAPrivateKeyFromPEM(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse RSA signing key %s: %w", cfg.AuthSigningKeyFile, err)
+		}
+		keys.signingMethod, keys.signingKey, public = jwt.SigningMethodRS256, private, private.Public()
+	case jwt.SigningMethodEdDSA.Alg():
+		private, err := jwt.ParseEdPrivateKeyFromPEM(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Ed25519 signing key %s: %w", cfg.AuthSigningKeyFile, err)
+		}
+		edPrivate, ok := private.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("signing key %s is not an Ed25519 key", cfg.AuthSigningKeyFile)
+		}
+		keys.signingMethod, keys.signingKey, public = jwt.SigningMethodEdDSA, edPrivate, edPrivate.Public()
+	default:
+		return nil, fmt.Errorf("unsupported jwt algorithm %q", cfg.AuthJwtAlgorithm)
+	}
+	keys.signingKID, err = keys.addVerifyKey(public)
+	if err != nil {
+		return nil, err
+	}
+	if err = keys.addVerifyKeyFiles(cfg.AuthVerifyKeyFiles); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (k *KeySet) addVerifyKeyFiles(files []string) error {
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read verification key: %w", err)
+		}
+		var public crypto.PublicKey
+		if rsaPublic, err := jwt.ParseRSAPublicKeyFromPEM(content); err == nil {
+			public = rsaPublic
+		} else if edPublic, err := jwt.ParseEdPublicKeyFromPEM(content); err == nil {
+			public = edPublic
+		} else {
+			return fmt.Errorf("verification key %s is neither RSA nor Ed25519 public key", file)
+		}
+		if _, err = k.addVerifyKey(public); err != nil {
+			return fmt.Errorf("verification key %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+func (k *KeySet) addVerifyKey(public crypto.PublicKey) (string, error) {
+	var key verifyKey
+	switch public := public.(type) {
+	case *rsa.PublicKey:
+		key = verifyKey{method: jwt.SigningMethodRS256, public: public, jwk: dto.JWK{
+			Kty: "RSA",
+			Alg: jwt.SigningMethodRS256.Alg(),
+			N:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}}
+		key.jwk.Kid = thumbprint(fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, key.jwk.E, key.jwk.N))
+	case ed25519.PublicKey:
+		key = verifyKey{method: jwt.SigningMethodEdDSA, public: public, jwk: dto.JWK{
+			Kty: "OKP",
+			Alg: jwt.SigningMethodEdDSA.Alg(),
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(public),
+		}}
+		key.jwk.Kid = thumbprint(fmt.Sprintf(`{"crv":"Ed25519","kty":"OKP","x":%q}`, key.jwk.X))
+	default:
+		return "", fmt.Errorf("unsupported public key type %T", public)
+	}
+	key.jwk.Use = "sig"
+	k.verifyKeys[key.jwk.Kid] = key
+	return key.jwk.Kid, nil
+}
+
+// thumbprint считает отпечаток ключа по RFC 7638 из его канонического JSON.
+func thumbprint(canonicalJWK string) string {
+	sum := sha256.Sum256([]byte(canonicalJWK))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// JWKS возвращает открытые ключи для проверки токенов другими сервисами; секрет HS256 не публикуется.
+func (k *KeySet) JWKS() dto.JWKS {
+	jwks := dto.JWKS{Keys: make([]dto.JWK, 0, len(k.verifyKeys))}
+	for _, key := range k.verifyKeys {
+		jwks.Keys = append(jwks.Keys, key.jwk)
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].Kid < jwks.Keys[j].Kid })
+	return jwks
+}
+
+func (k *KeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.signingMethod, claims)
+	if k.signingKID != "" {
+		token.Header["kid"] = k.signingKID
+	}
+	return token.SignedString(k.signingKey)
+}
+
+// keyFunc подбирает ключ проверки по kid и не даёт подменить алгоритм: алгоритм токена
+// должен совпадать с алгоритмом ключа.
+func (k *KeySet) keyFunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok || k.hmacSecret == nil {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return k.hmacSecret, nil
+	}
+	key, ok := k.verifyKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if t.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %v for key %q", t.Header["alg"], kid)
+	}
+	return key.public, nil
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"gophermart/internal/app/dto"
+	"gophermart/internal/config"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePEM(t *testing.T, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+	return path
+}
+
+func writePrivateKey(t *testing.T, key crypto.PrivateKey) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return writePEM(t, "PRIVATE KEY", der)
+}
+
+func writePublicKey(t *testing.T, key crypto.PublicKey) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return writePEM(t, "PUBLIC KEY", der)
+}
+
+func newKeyConfig(algorithm, signingKeyFile string, verifyKeyFiles ...string) *config.Config {
+	return &config.Config{
+		AuthSecretKey:       "generated",
+		AuthSecretGenerated: true,
+		AuthTokenExpired:    time.Hour,
+		AuthJwtAlgorithm:    algorithm,
+		AuthSigningKeyFile:  signingKeyFile,
+		AuthVerifyKeyFiles:  verifyKeyFiles,
+	}
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+func TestKeySet_RS256TokenVerifiableFromJWKS(t *testing.T) {
+	private := newRSAKey(t)
+	cfg := newKeyConfig("RS256", writePrivateKey(t, private))
+	keys, err := LoadKeySet(cfg)
+	require.NoError(t, err)
+	service := NewJwtServiceWithKeys(cfg, keys)
+
+	token, err := service.CreateJwt(7, 31)
+	require.NoError(t, err)
+
+	jwks := service.JWKS()
+	require.Len(t, jwks.Keys, 1)
+	jwk := jwks.Keys[0]
+	assert.Equal(t, "RS256", jwk.Alg)
+	assert.Equal(t, "sig", jwk.Use)
+
+	// другой сервис восстанавливает ключ из JWKS и проверяет токен по kid
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	require.NoError(t, err)
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	require.NoError(t, err)
+	public := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	claims := &dto.Claims{}
+	parsed, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		if t.Header["kid"] != jwk.Kid {
+			return nil, fmt.Errorf("unexpected kid %v", t.Header["kid"])
+		}
+		return public, nil
+	})
+	require.NoError(t, err)
+	assert.True(t, parsed.Valid)
+	assert.Equal(t, 7, claims.UserID)
+	assert.Equal(t, int64(31), claims.SessionID)
+}
+
+func TestKeySet_EdDSARoundTrip(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	cfg := newKeyConfig("EdDSA", writePrivateKey(t, private))
+	keys, err := LoadKeySet(cfg)
+	require.NoError(t, err)
+	service := NewJwtServiceWithKeys(cfg, keys)
+
+	token, err := service.CreateJwt(7, 31)
+	require.NoError(t, err)
+	claims, err := service.GetClaims(token)
+
+	require.NoError(t, err)
+	assert.Equal(t, 7, claims.UserID)
+	jwks := service.JWKS()
+	require.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "OKP", jwks.Keys[0].Kty)
+	assert.Equal(t, "Ed25519", jwks.Keys[0].Crv)
+}
+
+func TestKeySet_RotationKeepsPreviousKeyForVerification(t *testing.T) {
+	previous := newRSAKey(t)
+	previousCfg := newKeyConfig("RS256", writePrivateKey(t, previous))
+	previousKeys, err := LoadKeySet(previousCfg)
+	require.NoError(t, err)
+	issuedBefore, err := NewJwtServiceWithKeys(previousCfg, previousKeys).CreateJwt(7, 31)
+	require.NoError(t, err)
+
+	_, current, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	cfg := newKeyConfig("EdDSA", writePrivateKey(t, current), writePublicKey(t, previous.Public()))
+	keys, err := LoadKeySet(cfg)
+	require.NoError(t, err)
+	service := NewJwtServiceWithKeys(cfg, keys)
+
+	claims, err := service.GetClaims(issuedBefore)
+	require.NoError(t, err)
+	assert.Equal(t, int64(31), claims.SessionID)
+
+	issuedAfter, err := service.CreateJwt(7, 32)
+	require.NoError(t, err)
+	_, err = NewJwtServiceWithKeys(previousCfg, previousKeys).GetClaims(issuedAfter)
+	assert.Error(t, err)
+	assert.Len(t, service.JWKS().Keys, 2)
+}
+
+func TestKeySet_RejectsForgedTokens(t *testing.T) {
+	private := newRSAKey(t)
+	cfg := newKeyConfig("RS256", writePrivateKey(t, private))
+	keys, err := LoadKeySet(cfg)
+	require.NoError(t, err)
+	service := NewJwtServiceWithKeys(cfg, keys)
+	kid := service.JWKS().Keys[0].Kid
+	publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+	require.NoError(t, err)
+
+	tests := []struct {
+		sign func() (string, error)
+		name string
+	}{
+		{
+			name: "hmac signed with public key under rsa kid",
+			sign: func() (string, error) {
+				token := jwt.NewWithClaims(jwt.SigningMethodHS256, dto.Claims{UserID: 1})
+				token.Header["kid"] = kid
+				return token.SignedString(publicDER)
+			},
+		},
+		{
+			name: "hmac without kid when secret is generated",
+			sign: func() (string, error) {
+				return jwt.NewWithClaims(jwt.SigningMethodHS256, dto.Claims{UserID: 1}).SignedString([]byte("generated"))
+			},
+		},
+		{
+			name: "unknown kid",
+			sign: func() (string, error) {
+				token := jwt.NewWithClaims(jwt.SigningMethodRS256, dto.Claims{UserID: 1})
+				token.Header["kid"] = "unknown"
+				return token.SignedString(private)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := tt.sign()
+			require.NoError(t, err)
+
+			_, err = service.GetClaims(token)
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestThumbprint_RFC7638Example(t *testing.T) {
+	n := "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3" +
+		"oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdA" +
+		"ZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-" +
+		"kEgU8awapJzKnqDKgw"
+	canonical := fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, "AQAB", n)
+
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", thumbprint(canonical))
+}
@@ -10,29 +10,37 @@ import (
 )
 
 type JwtService interface {
-	CreateJwt(userID int) (string, error)
+	CreateJwt(userID int, sessionID int64) (string, error)
 	GetUserID(tokenString string) (int, error)
+	GetClaims(tokenString string) (*dto.Claims, error)
+	JWKS() dto.JWKS
 }
 
 type jwtService struct {
-	Cfg *config.Config
+	Cfg  *config.Config
+	Keys *KeySet
 }
 
+// NewJwtService подписывает токены секретом AuthSecretKey.
 func NewJwtService(cfg *config.Config) JwtService {
+	return NewJwtServiceWithKeys(cfg, NewHMACKeySet(cfg.AuthSecretKey))
+}
+
+func NewJwtServiceWithKeys(cfg *config.Config, keys *KeySet) JwtService {
 	return &jwtService{
-		Cfg: cfg,
+		Cfg:  cfg,
+		Keys: keys,
 	}
 }
 
-func (o *jwtService) CreateJwt(userID int) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, dto.Claims{
+func (o *jwtService) CreateJwt(userID int, sessionID int64) (string, error) {
+	tokenString, err := o.Keys.sign(dto.Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(o.Cfg.AuthTokenExpired)),
 		},
-		UserID: userID,
+		UserID:    userID,
+		SessionID: sessionID,
 	})
-
-	tokenString, err := token.SignedString([]byte(o.Cfg.AuthSecretKey))
 	if err != nil {
 		return "", fmt.Errorf("failed to SignedString: %w", err)
 	}
@@ -41,21 +49,27 @@ func (o *jwtService) CreateJwt(userID int) (string, error) {
 }
 
 func (o *jwtService) GetUserID(tokenString string) (int, error) {
+	claims, err := o.GetClaims(tokenString)
+	if err != nil {
+		return 0, err
+	}
+	return claims.UserID, nil
+}
+
+func (o *jwtService) GetClaims(tokenString string) (*dto.Claims, error) {
 	claims := &dto.Claims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims,
-		func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-			}
-			return []byte(o.Cfg.AuthSecretKey), nil
-		})
+	token, err := jwt.ParseWithClaims(tokenString, claims, o.Keys.keyFunc)
 	if err != nil {
-		return 0, fmt.Errorf("failed to ParseWithClaims: %w", err)
+		return nil, fmt.Errorf("failed to ParseWithClaims: %w", err)
 	}
 
 	if !token.Valid {
-		return 0, fmt.Errorf("failed auth token not valid: %w", err)
+		return nil, fmt.Errorf("failed auth token not valid: %w", err)
 	}
 
-	return claims.UserID, nil
+	return claims, nil
+}
+
+func (o *jwtService) JWKS() dto.JWKS {
+	return o.Keys.JWKS()
 }
@@ -22,7 +22,7 @@ func TestJwtService(t *testing.T) {
 
 	t.Run("CreateJwt should return a valid token", func(t *testing.T) {
 		userID := 123
-		tokenString, err := jwtService.CreateJwt(userID)
+		tokenString, err := jwtService.CreateJwt(userID, 1)
 		require.NoError(t, err)
 		require.NotEmpty(t, tokenString)
 
@@ -38,7 +38,7 @@ func TestJwtService(t *testing.T) {
 
 	t.Run("GetUserID should extract correct user ID from a valid token", func(t *testing.T) {
 		userID := 456
-		tokenString, err := jwtService.CreateJwt(userID)
+		tokenString, err := jwtService.CreateJwt(userID, 1)
 		require.NoError(t, err)
 
 		extractedUserID, err := jwtService.GetUserID(tokenString)
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"gophermart/internal/app/entities"
+	"gophermart/internal/app/repositories"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// postBalanceEntry блокирует строку пользователя, добавляет проводку в журнал и в той же транзакции
+// обновляет кэш баланса в users.balance. Все изменения баланса должны проходить через неё.
+func postBalanceEntry(
+	ctx context.Context,
+	tx pgx.Tx,
+	balanceEntryRepository repositories.BalanceEntryRepositoryInterface,
+	userRepository repositories.UserRepositoryInterface,
+	entry *entities.BalanceEntry,
+) error {
+	if _, err := userRepository.LockBalanceByUserID(ctx, tx, entry.UserID); err != nil {
+		return fmt.Errorf("failed to lock balance: %w", err)
+	}
+	if err := balanceEntryRepository.Store(ctx, tx, entry); err != nil {
+		return fmt.Errorf("failed to post balance entry: %w", err)
+	}
+	if err := userRepository.AddBalanceByUserID(ctx, tx, entry.Amount, entry.UserID); err != nil {
+		return fmt.Errorf("failed to update cached balance for user %d: %w", entry.UserID, err)
+	}
+	return nil
+}
@@ -7,7 +7,9 @@ import (
 	"gophermart/internal/app/apperrors"
 	"gophermart/internal/app/dto"
 	"gophermart/internal/app/entities"
+	"gophermart/internal/app/money"
 	"gophermart/internal/app/repositories"
+	"gophermart/internal/tracing"
 	"strconv"
 	"time"
 
@@ -38,7 +40,10 @@ func NewOrderService(
 	}
 }
 
-func (o *orderService) GetOrdersByUserID(ctx context.Context, userID int) ([]dto.OrdersResponseBody, error) {
+func (o *orderService) GetOrdersByUserID(ctx context.Context, userID int) (_ []dto.OrdersResponseBody, err error) {
+	ctx, span := tracer.Start(ctx, "OrderService.GetOrdersByUserID")
+	defer func() { tracing.End(span, err) }()
+
 	orders, err := o.OrderRepository.GetByUserID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get order by user id: %w", err)
@@ -47,9 +52,9 @@ func (o *orderService) GetOrdersByUserID(ctx context.Context, userID int) ([]dto
 	for i := range orders {
 		order := &orders[i]
 		status := entities.GetStatusName(int(order.StatusID))
-		var accrual *float64
+		var accrual *money.Money
 		if order.Accrual.Valid {
-			accrual = &order.Accrual.Float64
+			accrual = &order.Accrual.Money
 		}
 		response = append(response, dto.OrdersResponseBody{
 			Number:     strconv.Itoa(order.OrderID),
@@ -62,7 +67,10 @@ func (o *orderService) GetOrdersByUserID(ctx context.Context, userID int) ([]dto
 	return response, nil
 }
 
-func (o *orderService) SaveOrder(ctx context.Context, req dto.OrderBody) error {
+func (o *orderService) SaveOrder(ctx context.Context, req dto.OrderBody) (err error) {
+	ctx, span := tracer.Start(ctx, "OrderService.SaveOrder")
+	defer func() { tracing.End(span, err) }()
+
 	if err := o.validateOrder(ctx, req.OrderNumber, req.UserID); err != nil {
 		return fmt.Errorf("validate failed: %w", err)
 	}
@@ -78,15 +86,17 @@ func (o *orderService) SaveOrder(ctx context.Context, req dto.OrderBody) error {
 		return fmt.Errorf("failed to SaveOrder: %w", err)
 	}
 	defer func(tx pgx.Tx, ctx context.Context) {
-		err = tx.Rollback(ctx)
+		_ = tx.Rollback(ctx)
 	}(tx, ctx)
 
 	ID, err := o.OrderRepository.Store(ctx, tx, &order)
 	if err != nil {
 		return fmt.Errorf("failed to SaveOrder: %w", err)
 	}
+	// агент обработает заказ позже и в другой трассе: сохраняем ссылку на запрос загрузки
 	job := entities.Job{
-		OrderID: int64(ID),
+		OrderID:     int64(ID),
+		TraceParent: tracing.TraceParent(ctx),
 	}
 	err = o.JobRepository.SaveJob(ctx, tx, &job)
 	if err != nil {
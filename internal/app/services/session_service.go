@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"gophermart/internal/app/apperrors"
+	"gophermart/internal/app/dto"
+	"gophermart/internal/app/entities"
+	"gophermart/internal/app/repositories"
+	"gophermart/internal/config"
+	"gophermart/internal/tracing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const refreshTokenBytes = 32
+
+type SessionService interface {
+	Start(ctx context.Context, userID int) (*dto.TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (*dto.TokenPair, error)
+	Revoke(ctx context.Context, sessionID int64) error
+}
+
+type sessionService struct {
+	Pool              TxBeginner
+	SessionRepository repositories.SessionRepositoryInterface
+	JwtService        JwtService
+	Cfg               *config.Config
+}
+
+func NewSessionService(
+	db TxBeginner,
+	sessionRepository repositories.SessionRepositoryInterface,
+	jwtService JwtService,
+	cfg *config.Config,
+) SessionService {
+	return &sessionService{
+		Pool:              db,
+		SessionRepository: sessionRepository,
+		JwtService:        jwtService,
+		Cfg:               cfg,
+	}
+}
+
+// Start открывает сессию при входе и выдаёт первую пару токенов.
+func (s *sessionService) Start(ctx context.Context, userID int) (_ *dto.TokenPair, err error) {
+	ctx, span := tracer.Start(ctx, "SessionService.Start")
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func(tx pgx.Tx, ctx context.Context) {
+		_ = tx.Rollback(ctx)
+	}(tx, ctx)
+
+	session := entities.Session{UserID: userID}
+	if err = s.SessionRepository.CreateSession(ctx, tx, &session); err != nil {
+		return nil, fmt.Errorf("failed to CreateSession: %w", err)
+	}
+	pair, err := s.issue(ctx, tx, userID, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return pair, nil
+}
+
+// Refresh меняет токен обновления на новую пару. Токен одноразовый: повторное предъявление означает,
+// что он украден, поэтому отзывается вся сессия вместе с токенами, выданными взамен.
+func (s *sessionService) Refresh(ctx context.Context, refreshToken string) (_ *dto.TokenPair, err error) {
+	ctx, span := tracer.Start(ctx, "SessionService.Refresh")
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func(tx pgx.Tx, ctx context.Context) {
+		_ = tx.Rollback(ctx)
+	}(tx, ctx)
+
+	token, err := s.SessionRepository.LockRefreshToken(ctx, tx, hashRefreshToken(refreshToken))
+	if err != nil {
+		return nil, fmt.Errorf("failed to LockRefreshToken: %w", err)
+	}
+	if token.SessionRevokedAt != nil {
+		return nil, fmt.Errorf("session %d is revoked: %w", token.SessionID, apperrors.ErrInvalidRefreshToken)
+	}
+	if token.UsedAt != nil {
+		if err = s.SessionRepository.RevokeSession(ctx, tx, token.SessionID); err != nil {
+			return nil, fmt.Errorf("failed to RevokeSession: %w", err)
+		}
+		if err = tx.Commit(ctx); err != nil {
+			return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return nil, fmt.Errorf("session %d: %w", token.SessionID, apperrors.ErrRefreshTokenReused)
+	}
+	if !time.Now().Before(token.ExpiresAt) {
+		return nil, fmt.Errorf("refresh token expired at %s: %w", token.ExpiresAt, apperrors.ErrInvalidRefreshToken)
+	}
+
+	if err = s.SessionRepository.MarkRefreshTokenUsed(ctx, tx, token.ID); err != nil {
+		return nil, fmt.Errorf("failed to MarkRefreshTokenUsed: %w", err)
+	}
+	pair, err := s.issue(ctx, tx, token.UserID, token.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return pair, nil
+}
+
+// Revoke завершает сессию: её токены обновления и доступа больше не принимаются.
+func (s *sessionService) Revoke(ctx context.Context, sessionID int64) (err error) {
+	ctx, span := tracer.Start(ctx, "SessionService.Revoke")
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.SessionRepository.RevokeSession(ctx, nil, sessionID); err != nil {
+		return fmt.Errorf("failed to RevokeSession: %w", err)
+	}
+	return nil
+}
+
+func (s *sessionService) issue(ctx context.Context, tx pgx.Tx, userID int, sessionID int64) (*dto.TokenPair, error) {
+	raw := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	refreshToken := base64.RawURLEncoding.EncodeToString(raw)
+
+	token := entities.RefreshToken{
+		SessionID: sessionID,
+		Hash:      hashRefreshToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.Cfg.AuthRefreshTokenExpired),
+	}
+	if err := s.SessionRepository.StoreRefreshToken(ctx, tx, &token); err != nil {
+		return nil, fmt.Errorf("failed to StoreRefreshToken: %w", err)
+	}
+
+	accessToken, err := s.JwtService.CreateJwt(userID, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to CreateJwt: %w", err)
+	}
+	return &dto.TokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// hashRefreshToken — в базе хранится только хэш, утечка таблицы не даёт действующих токенов.
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"gophermart/internal/app/apperrors"
+	"gophermart/internal/app/entities"
+	"gophermart/internal/config"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSessionUserID = 7
+	testSessionID     = 31
+)
+
+var testSessionConfig = &config.Config{
+	AuthSecretKey:           "test_secret",
+	AuthTokenExpired:        time.Minute,
+	AuthRefreshTokenExpired: time.Hour,
+}
+
+func TestSessionService_StartIssuesTokenPair(t *testing.T) {
+	d := newServiceDeps(t)
+	jwt := NewJwtService(testSessionConfig)
+	service := NewSessionService(d.beginner, d.sessionRepo, jwt, testSessionConfig)
+
+	d.sessionRepo.EXPECT().CreateSession(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ pgx.Tx, session *entities.Session) error {
+			assert.Equal(t, testSessionUserID, session.UserID)
+			session.ID = testSessionID
+			return nil
+		})
+	var stored *entities.RefreshToken
+	d.sessionRepo.EXPECT().StoreRefreshToken(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ pgx.Tx, token *entities.RefreshToken) error {
+			stored = token
+			return nil
+		})
+
+	pair, err := service.Start(context.Background(), testSessionUserID)
+
+	require.NoError(t, err)
+	claims, err := jwt.GetClaims(pair.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, testSessionUserID, claims.UserID)
+	assert.Equal(t, int64(testSessionID), claims.SessionID)
+	require.NotNil(t, stored)
+	assert.Equal(t, int64(testSessionID), stored.SessionID)
+	assert.Equal(t, hashRefreshToken(pair.RefreshToken), stored.Hash)
+	assert.NotEqual(t, pair.RefreshToken, stored.Hash)
+	assert.True(t, d.beginner.txs[0].committed)
+}
+
+func TestSessionService_RefreshRotatesToken(t *testing.T) {
+	d := newServiceDeps(t)
+	jwt := NewJwtService(testSessionConfig)
+	service := NewSessionService(d.beginner, d.sessionRepo, jwt, testSessionConfig)
+	const presented = "presented-token"
+
+	current := &entities.RefreshToken{
+		ID:        5,
+		SessionID: testSessionID,
+		UserID:    testSessionUserID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	d.sessionRepo.EXPECT().LockRefreshToken(gomock.Any(), gomock.Any(), hashRefreshToken(presented)).Return(current, nil)
+	d.sessionRepo.EXPECT().MarkRefreshTokenUsed(gomock.Any(), gomock.Any(), int64(5)).Return(nil)
+	d.sessionRepo.EXPECT().StoreRefreshToken(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ pgx.Tx, token *entities.RefreshToken) error {
+			assert.Equal(t, int64(testSessionID), token.SessionID)
+			return nil
+		})
+
+	pair, err := service.Refresh(context.Background(), presented)
+
+	require.NoError(t, err)
+	assert.NotEqual(t, presented, pair.RefreshToken)
+	claims, err := jwt.GetClaims(pair.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, int64(testSessionID), claims.SessionID)
+	assert.True(t, d.beginner.txs[0].committed)
+}
+
+func TestSessionService_RefreshReuseRevokesFamily(t *testing.T) {
+	d := newServiceDeps(t)
+	jwt := NewJwtService(testSessionConfig)
+	service := NewSessionService(d.beginner, d.sessionRepo, jwt, testSessionConfig)
+	usedAt := time.Now().Add(-time.Minute)
+
+	d.sessionRepo.EXPECT().LockRefreshToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(&entities.RefreshToken{
+		ID:        5,
+		SessionID: testSessionID,
+		UserID:    testSessionUserID,
+		ExpiresAt: time.Now().Add(time.Hour),
+		UsedAt:    &usedAt,
+	}, nil)
+	d.sessionRepo.EXPECT().RevokeSession(gomock.Any(), gomock.Any(), int64(testSessionID)).Return(nil)
+
+	pair, err := service.Refresh(context.Background(), "stolen-token")
+
+	require.ErrorIs(t, err, apperrors.ErrRefreshTokenReused)
+	assert.Nil(t, pair)
+	// отзыв сессии должен сохраниться, хотя запрос отклонён
+	assert.True(t, d.beginner.txs[0].committed)
+}
+
+func TestSessionService_RefreshRejectsInvalidToken(t *testing.T) {
+	revokedAt := time.Now().Add(-time.Minute)
+	tests := []struct {
+		token *entities.RefreshToken
+		err   error
+		name  string
+	}{
+		{
+			name: "unknown token",
+			err:  apperrors.ErrInvalidRefreshToken,
+		},
+		{
+			name: "expired token",
+			token: &entities.RefreshToken{
+				ID:        5,
+				SessionID: testSessionID,
+				ExpiresAt: time.Now().Add(-time.Second),
+			},
+		},
+		{
+			name: "revoked session",
+			token: &entities.RefreshToken{
+				ID:               5,
+				SessionID:        testSessionID,
+				ExpiresAt:        time.Now().Add(time.Hour),
+				SessionRevokedAt: &revokedAt,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newServiceDeps(t)
+			jwt := NewJwtService(testSessionConfig)
+			service := NewSessionService(d.beginner, d.sessionRepo, jwt, testSessionConfig)
+			d.sessionRepo.EXPECT().LockRefreshToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(tt.token, tt.err)
+
+			pair, err := service.Refresh(context.Background(), "token")
+
+			require.ErrorIs(t, err, apperrors.ErrInvalidRefreshToken)
+			assert.Nil(t, pair)
+			assert.False(t, d.beginner.txs[0].committed)
+		})
+	}
+}
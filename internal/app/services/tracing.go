@@ -0,0 +1,6 @@
+package services
+
+import "go.opentelemetry.io/otel"
+
+// tracer открывает span уровня сервисов; запросы репозиториев попадают в них дочерними span базы.
+var tracer = otel.Tracer("gophermart/internal/app/services")
@@ -0,0 +1,12 @@
+package services
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// TxBeginner открывает транзакции; *pgxpool.Pool подходит, а в тестах его заменяет фейк.
+type TxBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
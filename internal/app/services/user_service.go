@@ -6,6 +6,7 @@ import (
 	"gophermart/internal/app/dto"
 	"gophermart/internal/app/entities"
 	"gophermart/internal/app/repositories"
+	"gophermart/internal/tracing"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -27,7 +28,10 @@ func NewUserService(
 	}
 }
 
-func (u *userService) Register(ctx context.Context, req dto.RegisterRequestBody) (entities.User, error) {
+func (u *userService) Register(ctx context.Context, req dto.RegisterRequestBody) (_ entities.User, err error) {
+	ctx, span := tracer.Start(ctx, "UserService.Register")
+	defer func() { tracing.End(span, err) }()
+
 	var user entities.User
 	user.Login = req.Login
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
@@ -43,7 +47,10 @@ func (u *userService) Register(ctx context.Context, req dto.RegisterRequestBody)
 	return newUser, nil
 }
 
-func (u *userService) Login(ctx context.Context, req dto.LoginRequestBody) (entities.User, error) {
+func (u *userService) Login(ctx context.Context, req dto.LoginRequestBody) (_ entities.User, err error) {
+	ctx, span := tracer.Start(ctx, "UserService.Login")
+	defer func() { tracing.End(span, err) }()
+
 	user, err := u.UserRepository.GetByLogin(ctx, req.Login)
 	if err != nil {
 		return user, fmt.Errorf("failed to GetByLogin: %w", err)
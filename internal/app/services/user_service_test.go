@@ -24,7 +24,7 @@ func TestLogin(t *testing.T) {
 	password := "password"
 	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 
-	m.EXPECT().GetByLogin(ctx, login).Return(entities.User{
+	m.EXPECT().GetByLogin(gomock.Any(), login).Return(entities.User{
 		Login:    login,
 		Password: string(hashedPassword),
 	}, nil)
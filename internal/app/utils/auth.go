@@ -7,9 +7,15 @@ import (
 
 type contextKey string
 
-const userIDKey contextKey = "userID"
+const (
+	userIDKey    contextKey = "userID"
+	sessionIDKey contextKey = "sessionID"
+)
 
 func SetUserID(ctx context.Context, userID int) context.Context {
+	if holder, ok := ctx.Value(userIDHolderKey).(*UserIDHolder); ok {
+		holder.userID, holder.set = userID, true
+	}
 	return context.WithValue(ctx, userIDKey, userID)
 }
 
@@ -20,3 +26,15 @@ func GetUserID(ctx context.Context) (int, error) {
 	}
 	return userID, nil
 }
+
+func SetSessionID(ctx context.Context, sessionID int64) context.Context {
+	return context.WithValue(ctx, sessionIDKey, sessionID)
+}
+
+func GetSessionID(ctx context.Context) (int64, error) {
+	sessionID, ok := ctx.Value(sessionIDKey).(int64)
+	if !ok {
+		return 0, errors.New("unauthorized")
+	}
+	return sessionID, nil
+}
@@ -27,3 +27,15 @@ func TestGetUserID_NoUserID(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, "unauthorized", err.Error())
 }
+
+func TestSetGetSessionID(t *testing.T) {
+	ctx := SetSessionID(context.Background(), 31)
+
+	sessionID, err := GetSessionID(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(31), sessionID)
+
+	_, err = GetSessionID(context.Background())
+	assert.Error(t, err)
+}
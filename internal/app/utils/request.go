@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	requestIDKey    contextKey = "requestID"
+	userIDHolderKey contextKey = "userIDHolder"
+)
+
+// UserIDHolder передаёт идентификатор пользователя наружу по цепочке middleware: журнал запросов
+// оборачивает Auth и не видит контекст, в который тот сохраняет пользователя.
+type UserIDHolder struct {
+	userID int
+	set    bool
+}
+
+func (h *UserIDHolder) UserID() (int, bool) {
+	return h.userID, h.set
+}
+
+func WithUserIDHolder(ctx context.Context) (context.Context, *UserIDHolder) {
+	holder := &UserIDHolder{}
+	return context.WithValue(ctx, userIDHolderKey, holder), holder
+}
+
+func SetRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+func GetRequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// ContextLogger добавляет к записям логгера идентификатор запроса, если ctx относится к HTTP-запросу,
+// и идентификатор трассы, если в ctx есть span.
+func ContextLogger(ctx context.Context, logger *zap.SugaredLogger) *zap.SugaredLogger {
+	if requestID := GetRequestID(ctx); requestID != "" {
+		logger = logger.With("request_id", requestID)
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		logger = logger.With("trace_id", spanContext.TraceID().String())
+	}
+	return logger
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestUserIDHolder(t *testing.T) {
+	ctx, holder := WithUserIDHolder(context.Background())
+
+	_, ok := holder.UserID()
+	assert.False(t, ok)
+
+	SetUserID(ctx, 7)
+
+	userID, ok := holder.UserID()
+	assert.True(t, ok)
+	assert.Equal(t, 7, userID)
+}
+
+func TestContextLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(core).Sugar()
+
+	ContextLogger(context.Background(), logger).Info("outside request")
+	ContextLogger(SetRequestID(context.Background(), "req-1"), logger).Info("inside request")
+
+	entries := logs.All()
+	assert.NotContains(t, entries[0].ContextMap(), "request_id")
+	assert.Equal(t, "req-1", entries[1].ContextMap()["request_id"])
+}
+
+func TestContextLogger_AddsTraceID(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(core).Sugar()
+	traceID := trace.TraceID{0x4b, 0xf9, 0x2f, 0x35}
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  trace.SpanID{0x01},
+	}))
+
+	ContextLogger(ctx, logger).Info("inside span")
+
+	assert.Equal(t, traceID.String(), logs.All()[0].ContextMap()["trace_id"])
+}
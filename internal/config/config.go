@@ -3,13 +3,40 @@ package config
 import "time"
 
 type Config struct {
-	DatabaseDsn        string
-	HTTPAddress        string
-	AccrualAddress     string
-	AuthSecretKey      string
-	AuthTokenExpired   time.Duration
-	PollInterval       time.Duration
-	RateLimit          int
-	AgentTimeoutClient time.Duration
-	AgentOrderLimit    int
+	DatabaseDsn              string
+	HTTPAddress              string
+	AccrualAddress           string
+	AuthSecretKey            string
+	AuthSecretFile           string
+	AuthSecretGenerated      bool
+	AuthJwtAlgorithm         string
+	AuthSigningKeyFile       string
+	AuthVerifyKeyFiles       []string
+	AuthTokenExpired         time.Duration
+	AuthRefreshTokenExpired  time.Duration
+	PollInterval             time.Duration
+	RateLimit                int
+	AgentTimeoutClient       time.Duration
+	AgentOrderLimit          int
+	AgentLeaseTimeout        time.Duration
+	AgentWorkerID            string
+	AgentMaxAttempts         int
+	AgentBackoffBase         time.Duration
+	AgentBackoffMax          time.Duration
+	AccrualRequestsPerMinute int
+	AccrualBreakerFailures   int
+	AccrualBreakerOpen       time.Duration
+	AccrualBreakerHalfOpen   int
+	AccrualCallbackSecret    string
+	AccrualCallbackTolerance time.Duration
+	AdminToken               string
+	ShutdownTimeout          time.Duration
+	IdempotencyKeyTTL        time.Duration
+	LogLevel                 string
+	LogFormat                string
+	TracingExporter          string
+	TracingEndpoint          string
+	TracingServiceName       string
+	// PrintConfig — вывести итоговую конфигурацию и завершить работу, задаётся только флагом.
+	PrintConfig bool
 }
@@ -0,0 +1,302 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// option связывает поле Config с флагом, переменной окружения и ключом файла конфигурации.
+// Ключ в файле — имя переменной окружения в нижнем регистре.
+type option struct {
+	field  func(*Config) any
+	flag   string
+	env    string
+	usage  string
+	secret bool
+}
+
+func (o option) key() string {
+	return strings.ToLower(o.env)
+}
+
+func options() []option {
+	return []option{
+		{
+			flag:  "a",
+			env:   "RUN_ADDRESS",
+			usage: "адрес и порт запуска сервиса",
+			field: func(c *Config) any { return &c.HTTPAddress },
+		},
+		{
+			flag:   "d",
+			env:    "DATABASE_URI",
+			usage:  "адрес подключения к базе данных",
+			field:  func(c *Config) any { return &c.DatabaseDsn },
+			secret: true,
+		},
+		{
+			flag:  "r",
+			env:   "ACCRUAL_SYSTEM_ADDRESS",
+			usage: "адрес системы расчёта начислений",
+			field: func(c *Config) any { return &c.AccrualAddress },
+		},
+		{
+			flag:   "auth-secret",
+			env:    "AUTH_SECRET_KEY",
+			usage:  "секрет подписи токенов HS256",
+			field:  func(c *Config) any { return &c.AuthSecretKey },
+			secret: true,
+		},
+		{
+			flag:  "auth-secret-file",
+			env:   "AUTH_SECRET_KEY_FILE",
+			usage: "файл с секретом подписи токенов HS256",
+			field: func(c *Config) any { return &c.AuthSecretFile },
+		},
+		{
+			flag:  "jwt-algorithm",
+			env:   "AUTH_JWT_ALGORITHM",
+			usage: "алгоритм подписи токенов доступа: HS256, RS256 или EdDSA",
+			field: func(c *Config) any { return &c.AuthJwtAlgorithm },
+		},
+		{
+			flag:  "jwt-signing-key",
+			env:   "AUTH_JWT_SIGNING_KEY_FILE",
+			usage: "PEM-файл закрытого ключа подписи для RS256 и EdDSA",
+			field: func(c *Config) any { return &c.AuthSigningKeyFile },
+		},
+		{
+			flag:  "jwt-verify-keys",
+			env:   "AUTH_JWT_VERIFY_KEY_FILES",
+			usage: "PEM-файлы открытых ключей через запятую, которые ещё принимаются при ротации",
+			field: func(c *Config) any { return &c.AuthVerifyKeyFiles },
+		},
+		{
+			flag:  "access-token-ttl",
+			env:   "AUTH_ACCESS_TOKEN_TTL",
+			usage: "время жизни токена доступа",
+			field: func(c *Config) any { return &c.AuthTokenExpired },
+		},
+		{
+			flag:  "refresh-token-ttl",
+			env:   "AUTH_REFRESH_TOKEN_TTL",
+			usage: "время жизни токена обновления; каждое обновление выдаёт новый токен",
+			field: func(c *Config) any { return &c.AuthRefreshTokenExpired },
+		},
+		{
+			flag:  "poll-interval",
+			env:   "POLL_INTERVAL",
+			usage: "интервал опроса очереди заданий",
+			field: func(c *Config) any { return &c.PollInterval },
+		},
+		{
+			flag:  "rate-limit",
+			env:   "RATE_LIMIT",
+			usage: "число воркеров, одновременно опрашивающих систему начислений",
+			field: func(c *Config) any { return &c.RateLimit },
+		},
+		{
+			flag:  "agent-timeout",
+			env:   "AGENT_TIMEOUT_CLIENT",
+			usage: "таймаут запроса к системе начислений",
+			field: func(c *Config) any { return &c.AgentTimeoutClient },
+		},
+		{
+			flag:  "order-limit",
+			env:   "AGENT_ORDER_LIMIT",
+			usage: "число заданий, забираемых из очереди за один опрос",
+			field: func(c *Config) any { return &c.AgentOrderLimit },
+		},
+		{
+			flag:  "lease-timeout",
+			env:   "AGENT_LEASE_TIMEOUT",
+			usage: "время аренды задания экземпляром сервиса, после которого его заберёт другой экземпляр",
+			field: func(c *Config) any { return &c.AgentLeaseTimeout },
+		},
+		{
+			flag:  "worker-id",
+			env:   "AGENT_WORKER_ID",
+			usage: "идентификатор экземпляра сервиса в очереди заданий",
+			field: func(c *Config) any { return &c.AgentWorkerID },
+		},
+		{
+			flag:  "max-attempts",
+			env:   "AGENT_MAX_ATTEMPTS",
+			usage: "число попыток опроса заказа, после которого задание переносится в dead-letter",
+			field: func(c *Config) any { return &c.AgentMaxAttempts },
+		},
+		{
+			flag:  "backoff-base",
+			env:   "AGENT_BACKOFF_BASE",
+			usage: "задержка перед первым повтором задания",
+			field: func(c *Config) any { return &c.AgentBackoffBase },
+		},
+		{
+			flag:  "backoff-max",
+			env:   "AGENT_BACKOFF_MAX",
+			usage: "максимальная задержка между повторами задания",
+			field: func(c *Config) any { return &c.AgentBackoffMax },
+		},
+		{
+			flag:  "accrual-rpm",
+			env:   "ACCRUAL_RPM",
+			usage: "лимит запросов в минуту к системе начислений, 0 — без ограничения до первого ответа 429",
+			field: func(c *Config) any { return &c.AccrualRequestsPerMinute },
+		},
+		{
+			flag:  "breaker-failures",
+			env:   "ACCRUAL_BREAKER_FAILURES",
+			usage: "число ошибок системы начислений подряд, после которого запросы к ней приостанавливаются",
+			field: func(c *Config) any { return &c.AccrualBreakerFailures },
+		},
+		{
+			flag:  "breaker-open-timeout",
+			env:   "ACCRUAL_BREAKER_OPEN_TIMEOUT",
+			usage: "время, на которое приостанавливаются запросы к недоступной системе начислений",
+			field: func(c *Config) any { return &c.AccrualBreakerOpen },
+		},
+		{
+			flag:  "breaker-half-open",
+			env:   "ACCRUAL_BREAKER_HALF_OPEN",
+			usage: "число успешных пробных запросов, после которых запросы к системе начислений возобновляются",
+			field: func(c *Config) any { return &c.AccrualBreakerHalfOpen },
+		},
+		{
+			flag:   "callback-secret",
+			env:    "ACCRUAL_CALLBACK_SECRET",
+			usage:  "общий секрет подписи уведомлений системы начислений, пустое значение отключает уведомления",
+			field:  func(c *Config) any { return &c.AccrualCallbackSecret },
+			secret: true,
+		},
+		{
+			flag:  "callback-tolerance",
+			env:   "ACCRUAL_CALLBACK_TOLERANCE",
+			usage: "допустимое расхождение времени подписи уведомления с текущим временем",
+			field: func(c *Config) any { return &c.AccrualCallbackTolerance },
+		},
+		{
+			flag:   "admin-token",
+			env:    "ADMIN_TOKEN",
+			usage:  "токен оператора для служебных запросов, пустое значение отключает их",
+			field:  func(c *Config) any { return &c.AdminToken },
+			secret: true,
+		},
+		{
+			flag:  "shutdown-timeout",
+			env:   "SHUTDOWN_TIMEOUT",
+			usage: "время на корректное завершение запросов и заданий при остановке",
+			field: func(c *Config) any { return &c.ShutdownTimeout },
+		},
+		{
+			flag:  "idempotency-ttl",
+			env:   "IDEMPOTENCY_KEY_TTL",
+			usage: "время хранения ответа на запрос с Idempotency-Key",
+			field: func(c *Config) any { return &c.IdempotencyKeyTTL },
+		},
+		{
+			flag:  "log-level",
+			env:   "LOG_LEVEL",
+			usage: "уровень логирования: debug, info, warn или error",
+			field: func(c *Config) any { return &c.LogLevel },
+		},
+		{
+			flag:  "log-format",
+			env:   "LOG_FORMAT",
+			usage: "формат логов: console для разработки или json для сбора логов",
+			field: func(c *Config) any { return &c.LogFormat },
+		},
+		{
+			flag:  "tracing-exporter",
+			env:   "TRACING_EXPORTER",
+			usage: "экспорт трасс: none, otlp для коллектора OpenTelemetry или stdout для локальной отладки",
+			field: func(c *Config) any { return &c.TracingExporter },
+		},
+		{
+			flag:  "tracing-endpoint",
+			env:   "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT",
+			usage: "полный адрес приёма трасс OTLP/HTTP, по умолчанию http://localhost:4318/v1/traces",
+			field: func(c *Config) any { return &c.TracingEndpoint },
+		},
+		{
+			flag:  "tracing-service-name",
+			env:   "OTEL_SERVICE_NAME",
+			usage: "имя сервиса в трассах",
+			field: func(c *Config) any { return &c.TracingServiceName },
+		},
+	}
+}
+
+func setValue(field any, raw string) error {
+	switch field := field.(type) {
+	case *string:
+		*field = raw
+	case *[]string:
+		*field = splitList(raw)
+	case *int:
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q: %w", raw, err)
+		}
+		*field = value
+	case *time.Duration:
+		value, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %w", raw, err)
+		}
+		*field = value
+	default:
+		return fmt.Errorf("unsupported field type %T", field)
+	}
+	return nil
+}
+
+func formatValue(field any) string {
+	switch field := field.(type) {
+	case *string:
+		return *field
+	case *[]string:
+		return strings.Join(*field, ",")
+	case *int:
+		return strconv.Itoa(*field)
+	case *time.Duration:
+		return field.String()
+	default:
+		return ""
+	}
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// flagValue запоминает значение флага, чтобы применить его поверх файла конфигурации.
+type flagValue struct {
+	pending *[]pendingFlag
+	opt     option
+	def     string
+}
+
+type pendingFlag struct {
+	opt option
+	raw string
+}
+
+func (v *flagValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.def
+}
+
+func (v *flagValue) Set(raw string) error {
+	*v.pending = append(*v.pending, pendingFlag{opt: v.opt, raw: raw})
+	return nil
+}
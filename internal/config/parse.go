@@ -1,57 +1,232 @@
 package config
 
 import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
 )
 
 const (
-	defaultAuthTokenExpiration = 3 * time.Hour
+	defaultAuthTokenExpiration = 15 * time.Minute
+	defaultRefreshTokenTTL     = 30 * 24 * time.Hour
+	defaultJwtAlgorithm        = "HS256"
+	generatedSecretBytes       = 32
 	defaultPollInterval        = 6 * time.Second
+	defaultRateLimit           = 1
 	defaultAgentOrderLimit     = 1
 	defaultAgentTimeout        = 2 * time.Second
+	defaultShutdownTimeout     = 10 * time.Second
+	defaultIdempotencyKeyTTL   = 24 * time.Hour
+	defaultAgentLeaseTimeout   = 30 * time.Second
+	defaultAgentMaxAttempts    = 10
+	defaultAgentBackoffBase    = 10 * time.Second
+	defaultAgentBackoffMax     = time.Hour
+	defaultBreakerFailures     = 5
+	defaultBreakerOpen         = 30 * time.Second
+	defaultBreakerHalfOpen     = 1
+	defaultCallbackTolerance   = 5 * time.Minute
+	defaultLogLevel            = "info"
+	defaultLogFormat           = "console"
+	defaultTracingExporter     = "none"
+	defaultTracingServiceName  = "gophermart"
+	configFileEnv              = "CONFIG"
 )
 
 func ParseFlags() (*Config, error) {
-	httpAddressFlag := flag.String("a", "", "адрес и порт запуска сервиса")
-	databaseDsnFlag := flag.String("d", "", "адрес подключения к базе данных")
-	accrualAddressFlag := flag.String("r", "", "адрес системы расчёта начислений")
+	return Parse(flag.CommandLine, os.Args[1:], os.LookupEnv)
+}
 
-	flag.Parse()
+// Parse собирает конфигурацию по возрастанию приоритета: значения по умолчанию, файл конфигурации,
+// флаги, переменные окружения. Возвращает сразу все ошибки в значениях.
+func Parse(fs *flag.FlagSet, args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := defaultConfig()
+	opts := options()
 
-	uknownArguments := flag.Args()
-	if err := validateUnknownArgs(uknownArguments); err != nil {
+	var pending []pendingFlag
+	for _, opt := range opts {
+		fs.Var(&flagValue{pending: &pending, opt: opt, def: formatValue(opt.field(cfg))}, opt.flag, opt.usage)
+	}
+	configFileFlag := fs.String("config", "", "файл конфигурации YAML или TOML")
+	fs.BoolVar(&cfg.PrintConfig, "print-config", false, "вывести итоговую конфигурацию без секретов и завершить работу")
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("parse flags: %w", err)
+	}
+	if err := validateUnknownArgs(fs.Args()); err != nil {
 		return nil, fmt.Errorf("read flag UnknownArgs: %w", err)
 	}
-	httpAddress := getStringValue("RUN_ADDRESS", *httpAddressFlag)
-	databaseDsn := getStringValue("DATABASE_URI", *databaseDsnFlag)
-	accrualAddress := getStringValue("ACCRUAL_SYSTEM_ADDRESS", *accrualAddressFlag)
-	applicationKey := "supersecretkey"
 
-	agentOrderLimit := defaultAgentOrderLimit
-	agentTimeoutClient := defaultAgentTimeout
-	if agentOrderLimit > int(agentTimeoutClient/time.Second) {
-		return nil, fmt.Errorf(
-			"AgentOrderLimit (%d) превышает допустимое количество для заданного AgentTimeoutClient (%s)",
-			agentOrderLimit,
-			agentTimeoutClient,
-		)
+	var errs []error
+	configFile := *configFileFlag
+	if envValue, exists := lookupEnv(configFileEnv); exists {
+		configFile = envValue
+	}
+	if configFile != "" {
+		if err := loadFile(cfg, opts, configFile); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, flagValue := range pending {
+		if err := setValue(flagValue.opt.field(cfg), flagValue.raw); err != nil {
+			errs = append(errs, fmt.Errorf("read flag -%s: %w", flagValue.opt.flag, err))
+		}
+	}
+	for _, opt := range opts {
+		if envValue, exists := lookupEnv(opt.env); exists {
+			if err := setValue(opt.field(cfg), envValue); err != nil {
+				errs = append(errs, fmt.Errorf("read %s: %w", opt.env, err))
+			}
+		}
+	}
+
+	secret, generated, err := getSecretValue(cfg.AuthSecretKey, cfg.AuthSecretFile)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("read AUTH_SECRET_KEY: %w", err))
 	}
-	rateLimit := 1
+	cfg.AuthSecretKey, cfg.AuthSecretGenerated = secret, generated
 
+	if err := errors.Join(append(errs, cfg.validate())...); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func defaultConfig() *Config {
 	return &Config{
-		DatabaseDsn:        databaseDsn,
-		HTTPAddress:        httpAddress,
-		AccrualAddress:     accrualAddress,
-		AuthSecretKey:      applicationKey,
-		AuthTokenExpired:   defaultAuthTokenExpiration,
-		PollInterval:       defaultPollInterval,
-		RateLimit:          rateLimit,
-		AgentTimeoutClient: agentTimeoutClient,
-		AgentOrderLimit:    agentOrderLimit,
-	}, nil
+		AuthJwtAlgorithm:         defaultJwtAlgorithm,
+		AuthTokenExpired:         defaultAuthTokenExpiration,
+		AuthRefreshTokenExpired:  defaultRefreshTokenTTL,
+		PollInterval:             defaultPollInterval,
+		RateLimit:                defaultRateLimit,
+		AgentTimeoutClient:       defaultAgentTimeout,
+		AgentOrderLimit:          defaultAgentOrderLimit,
+		AgentLeaseTimeout:        defaultAgentLeaseTimeout,
+		AgentWorkerID:            defaultWorkerID(),
+		AgentMaxAttempts:         defaultAgentMaxAttempts,
+		AgentBackoffBase:         defaultAgentBackoffBase,
+		AgentBackoffMax:          defaultAgentBackoffMax,
+		AccrualBreakerFailures:   defaultBreakerFailures,
+		AccrualBreakerOpen:       defaultBreakerOpen,
+		AccrualBreakerHalfOpen:   defaultBreakerHalfOpen,
+		AccrualCallbackTolerance: defaultCallbackTolerance,
+		ShutdownTimeout:          defaultShutdownTimeout,
+		IdempotencyKeyTTL:        defaultIdempotencyKeyTTL,
+		LogLevel:                 defaultLogLevel,
+		LogFormat:                defaultLogFormat,
+		TracingExporter:          defaultTracingExporter,
+		TracingServiceName:       defaultTracingServiceName,
+	}
+}
+
+// loadFile применяет файл конфигурации; формат определяется по расширению.
+func loadFile(cfg *Config, opts []option, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	values := map[string]any{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &values)
+	case ".toml":
+		_, err = toml.Decode(string(content), &values)
+	default:
+		return fmt.Errorf("файл конфигурации %s должен иметь расширение .yaml, .yml или .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	byKey := make(map[string]option, len(opts))
+	for _, opt := range opts {
+		byKey[opt.key()] = opt
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		opt, ok := byKey[key]
+		if !ok {
+			errs = append(errs, fmt.Errorf("config file %s: неизвестный ключ %q", path, key))
+			continue
+		}
+		raw, err := fileValue(values[key])
+		if err == nil {
+			err = setValue(opt.field(cfg), raw)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("config file %s: read %s: %w", path, key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// fileValue приводит значение из файла к строке того же вида, что у флага и переменной окружения.
+func fileValue(value any) (string, error) {
+	switch value := value.(type) {
+	case string:
+		return value, nil
+	case int, int64, float64, bool:
+		return fmt.Sprint(value), nil
+	case []any:
+		items := make([]string, 0, len(value))
+		for _, item := range value {
+			raw, err := fileValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, raw)
+		}
+		return strings.Join(items, ","), nil
+	default:
+		return "", fmt.Errorf("unsupported value %v", value)
+	}
+}
+
+// defaultWorkerID отличает экземпляры сервиса, запущенные на разных хостах или в одном контейнере.
+func defaultWorkerID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "gophermart"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+// getSecretValue берёт секрет из значения, затем из файла. Если не задано ни то, ни другое,
+// генерирует случайный: токены не переживут перезапуск и не подойдут другим экземплярам сервиса.
+func getSecretValue(value, file string) (string, bool, error) {
+	if value != "" {
+		return value, false, nil
+	}
+	if file != "" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to read secret file: %w", err)
+		}
+		secret := strings.TrimSpace(string(content))
+		if secret == "" {
+			return "", false, fmt.Errorf("secret file %s is empty", file)
+		}
+		return secret, false, nil
+	}
+	raw := make([]byte, generatedSecretBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", false, fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), true, nil
 }
 
 func validateUnknownArgs(unknownArgs []string) error {
@@ -60,11 +235,3 @@ func validateUnknownArgs(unknownArgs []string) error {
 	}
 	return nil
 }
-
-func getStringValue(env, flagValue string) string {
-	if envValue, exists := os.LookupEnv(env); exists {
-		return envValue
-	} else {
-		return flagValue
-	}
-}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "xxxxx"
+
+var dsnPasswordPattern = regexp.MustCompile(`(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// Print выводит итоговую конфигурацию в формате файла конфигурации, скрывая секреты.
+func (c *Config) Print(w io.Writer) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	for _, opt := range options() {
+		field := opt.field(c)
+		value := any(formatValue(field))
+		switch field := field.(type) {
+		case *int:
+			value = *field
+		case *[]string:
+			value = *field
+		}
+		if opt.secret {
+			value = redact(opt, formatValue(field))
+		}
+		var valueNode yaml.Node
+		if err := valueNode.Encode(value); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", opt.key(), err)
+		}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: opt.key()}, &valueNode)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return fmt.Errorf("failed to print config: %w", err)
+	}
+	return encoder.Close()
+}
+
+// redact скрывает секрет; в строке подключения к базе данных скрывается только пароль.
+func redact(opt option, value string) string {
+	if value == "" {
+		return ""
+	}
+	if opt.env != "DATABASE_URI" {
+		return redacted
+	}
+	if u, err := url.Parse(value); err == nil && u.Scheme != "" {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+		}
+		return u.String()
+	}
+	return dsnPasswordPattern.ReplaceAllString(value, "${1}"+redacted)
+}
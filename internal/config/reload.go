@@ -0,0 +1,95 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// liveKeys — параметры, которые применяются без перезапуска; остальные изменения ждут перезапуска сервиса.
+var liveKeys = map[string]bool{
+	"poll_interval":     true,
+	"rate_limit":        true,
+	"agent_order_limit": true,
+	"accrual_rpm":       true,
+	"log_level":         true,
+}
+
+// ReloadResult перечисляет ключи, изменившиеся при перечитывании конфигурации.
+type ReloadResult struct {
+	Applied         []string `json:"applied"`
+	RestartRequired []string `json:"restart_required"`
+}
+
+// Reloader перечитывает конфигурацию по SIGHUP или запросу оператора и передаёт подписчикам
+// применённые значения. Конфигурация с ошибками отклоняется целиком, прежние значения остаются в силе.
+type Reloader struct {
+	load      func() (*Config, error)
+	current   *Config
+	listeners []func(*Config)
+	mu        sync.Mutex
+}
+
+func NewReloader(cfg *Config, load func() (*Config, error)) *Reloader {
+	return &Reloader{current: cfg, load: load}
+}
+
+// LoadFromCommandLine повторно разбирает аргументы запуска: файл конфигурации перечитывается,
+// а флаги и переменные окружения по-прежнему имеют приоритет над ним.
+func LoadFromCommandLine() (*Config, error) {
+	return Parse(flag.NewFlagSet(os.Args[0], flag.ContinueOnError), os.Args[1:], os.LookupEnv)
+}
+
+// Subscribe регистрирует обработчик применённой конфигурации; вызывается под блокировкой Reloader.
+func (r *Reloader) Subscribe(fn func(*Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, fn)
+}
+
+func (r *Reloader) Current() *Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+func (r *Reloader) Reload() (ReloadResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loaded, err := r.load()
+	if err != nil {
+		return ReloadResult{}, fmt.Errorf("failed to reload config: %w", err)
+	}
+
+	result := ReloadResult{Applied: []string{}, RestartRequired: []string{}}
+	next := *r.current
+	for _, opt := range options() {
+		if opt.env == "AUTH_SECRET_KEY" && r.current.AuthSecretGenerated && loaded.AuthSecretGenerated {
+			// случайный секрет генерируется заново при каждом разборе, это не изменение
+			continue
+		}
+		value := formatValue(opt.field(loaded))
+		if value == formatValue(opt.field(r.current)) {
+			continue
+		}
+		if !liveKeys[opt.key()] {
+			result.RestartRequired = append(result.RestartRequired, opt.key())
+			continue
+		}
+		if err = setValue(opt.field(&next), value); err != nil {
+			return ReloadResult{}, fmt.Errorf("failed to apply %s: %w", opt.key(), err)
+		}
+		result.Applied = append(result.Applied, opt.key())
+	}
+
+	if err = next.validate(); err != nil {
+		return ReloadResult{}, fmt.Errorf("failed to reload config: %w", err)
+	}
+	r.current = &next
+	for _, fn := range r.listeners {
+		fn(r.current)
+	}
+	return result, nil
+}
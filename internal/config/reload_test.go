@@ -0,0 +1,78 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloader_Reload(t *testing.T) {
+	current := defaultConfig()
+	current.DatabaseDsn = "postgres://current"
+	current.AuthSecretKey, current.AuthSecretGenerated = "generated-1", true
+
+	loaded := *current
+	loaded.AuthSecretKey = "generated-2"
+	loaded.RateLimit = 4
+	loaded.PollInterval = time.Second
+	loaded.LogLevel = "debug"
+	loaded.HTTPAddress = "localhost:9090"
+
+	reloader := NewReloader(current, func() (*Config, error) { return &loaded, nil })
+	var notified []*Config
+	reloader.Subscribe(func(cfg *Config) { notified = append(notified, cfg) })
+
+	result, err := reloader.Reload()
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"poll_interval", "rate_limit", "log_level"}, result.Applied)
+	assert.Equal(t, []string{"run_address"}, result.RestartRequired)
+	require.Len(t, notified, 1)
+	applied := reloader.Current()
+	assert.Same(t, applied, notified[0])
+	assert.Equal(t, 4, applied.RateLimit)
+	assert.Equal(t, time.Second, applied.PollInterval)
+	assert.Equal(t, "debug", applied.LogLevel)
+	assert.Equal(t, "", applied.HTTPAddress, "address changes only after restart")
+	assert.Equal(t, "generated-1", applied.AuthSecretKey)
+}
+
+func TestReloader_RejectsInvalidConfig(t *testing.T) {
+	current := defaultConfig()
+	current.DatabaseDsn = "postgres://current"
+
+	tests := []struct {
+		load func() (*Config, error)
+		name string
+	}{
+		{
+			name: "parse error",
+			load: func() (*Config, error) {
+				return nil, errors.New("RateLimit (0) должен быть положительным")
+			},
+		},
+		{
+			name: "order limit exceeds current client timeout",
+			load: func() (*Config, error) {
+				loaded := *current
+				loaded.AgentOrderLimit = 5
+				loaded.AgentTimeoutClient = 10 * time.Second
+				return &loaded, nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reloader := NewReloader(current, tt.load)
+			reloader.Subscribe(func(*Config) { t.Fatal("listener must not be called") })
+
+			_, err := reloader.Reload()
+
+			require.Error(t, err)
+			assert.Same(t, current, reloader.Current())
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// validate проверяет значения целиком и возвращает все найденные ошибки, а не только первую.
+func (c *Config) validate() error {
+	var errs []error
+	if c.DatabaseDsn == "" {
+		errs = append(errs, errors.New("DatabaseDsn обязателен"))
+	}
+	switch c.AuthJwtAlgorithm {
+	case "HS256":
+	case "RS256", "EdDSA":
+		if c.AuthSigningKeyFile == "" {
+			errs = append(errs, fmt.Errorf("для алгоритма %s нужен закрытый ключ AuthSigningKeyFile", c.AuthJwtAlgorithm))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("AuthJwtAlgorithm (%s) должен быть HS256, RS256 или EdDSA", c.AuthJwtAlgorithm))
+	}
+	if c.AuthTokenExpired <= 0 || c.AuthRefreshTokenExpired <= c.AuthTokenExpired {
+		errs = append(errs, fmt.Errorf(
+			"AuthTokenExpired (%s) должен быть положительным и меньше AuthRefreshTokenExpired (%s)",
+			c.AuthTokenExpired,
+			c.AuthRefreshTokenExpired,
+		))
+	}
+	if c.PollInterval <= 0 {
+		errs = append(errs, fmt.Errorf("PollInterval (%s) должен быть положительным", c.PollInterval))
+	}
+	if c.RateLimit < 1 {
+		errs = append(errs, fmt.Errorf("RateLimit (%d) должен быть положительным", c.RateLimit))
+	}
+	if c.AgentOrderLimit < 1 || c.AgentTimeoutClient <= 0 {
+		errs = append(errs, fmt.Errorf(
+			"AgentOrderLimit (%d) и AgentTimeoutClient (%s) должны быть положительными",
+			c.AgentOrderLimit,
+			c.AgentTimeoutClient,
+		))
+	} else if c.AgentOrderLimit > int(c.AgentTimeoutClient/time.Second) {
+		errs = append(errs, fmt.Errorf(
+			"AgentOrderLimit (%d) превышает допустимое количество для заданного AgentTimeoutClient (%s)",
+			c.AgentOrderLimit,
+			c.AgentTimeoutClient,
+		))
+	}
+	if c.AgentLeaseTimeout <= c.AgentTimeoutClient {
+		errs = append(errs, fmt.Errorf(
+			"AgentLeaseTimeout (%s) должен превышать AgentTimeoutClient (%s)",
+			c.AgentLeaseTimeout,
+			c.AgentTimeoutClient,
+		))
+	}
+	if c.AgentWorkerID == "" {
+		errs = append(errs, errors.New("AgentWorkerID не может быть пустым"))
+	}
+	if c.AgentMaxAttempts < 1 {
+		errs = append(errs, fmt.Errorf("AgentMaxAttempts (%d) должен быть положительным", c.AgentMaxAttempts))
+	}
+	if c.AgentBackoffBase <= 0 || c.AgentBackoffMax < c.AgentBackoffBase {
+		errs = append(errs, fmt.Errorf(
+			"AgentBackoffBase (%s) должен быть положительным и не превышать AgentBackoffMax (%s)",
+			c.AgentBackoffBase,
+			c.AgentBackoffMax,
+		))
+	}
+	if c.AccrualRequestsPerMinute < 0 {
+		errs = append(errs, fmt.Errorf(
+			"AccrualRequestsPerMinute (%d) не может быть отрицательным",
+			c.AccrualRequestsPerMinute,
+		))
+	}
+	if c.AccrualBreakerFailures < 1 || c.AccrualBreakerHalfOpen < 1 || c.AccrualBreakerOpen <= 0 {
+		errs = append(errs, fmt.Errorf(
+			"AccrualBreakerFailures (%d), AccrualBreakerHalfOpen (%d) и AccrualBreakerOpen (%s) должны быть положительными",
+			c.AccrualBreakerFailures,
+			c.AccrualBreakerHalfOpen,
+			c.AccrualBreakerOpen,
+		))
+	}
+	if c.AccrualCallbackTolerance <= 0 {
+		errs = append(errs, fmt.Errorf(
+			"AccrualCallbackTolerance (%s) должен быть положительным",
+			c.AccrualCallbackTolerance,
+		))
+	}
+	if c.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("ShutdownTimeout (%s) должен быть положительным", c.ShutdownTimeout))
+	}
+	if c.IdempotencyKeyTTL <= 0 {
+		errs = append(errs, fmt.Errorf("IdempotencyKeyTTL (%s) должен быть положительным", c.IdempotencyKeyTTL))
+	}
+	if _, err := zapcore.ParseLevel(c.LogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("LogLevel (%s) должен быть debug, info, warn или error", c.LogLevel))
+	}
+	if c.LogFormat != "console" && c.LogFormat != "json" {
+		errs = append(errs, fmt.Errorf("LogFormat (%s) должен быть console или json", c.LogFormat))
+	}
+	switch c.TracingExporter {
+	case "none":
+	case "otlp", "stdout":
+		if c.TracingServiceName == "" {
+			errs = append(errs, errors.New("TracingServiceName не может быть пустым"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("TracingExporter (%s) должен быть none, otlp или stdout", c.TracingExporter))
+	}
+	return errors.Join(errs...)
+}
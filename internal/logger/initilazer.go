@@ -4,11 +4,33 @@ import (
 	"fmt"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
-func InitilazerLogger() (*zap.SugaredLogger, error) {
-	configLogger := zap.NewDevelopmentConfig()
-	configLogger.Level = zap.NewAtomicLevelAt(zap.InfoLevel)
+const (
+	FormatConsole = "console"
+	FormatJSON    = "json"
+)
+
+// level общий для всех логгеров сервиса и меняется без перезапуска.
+var level = zap.NewAtomicLevelAt(zap.InfoLevel)
+
+// InitilazerLogger создаёт логгер в формате console для разработки или json для сбора логов.
+func InitilazerLogger(format string) (*zap.SugaredLogger, error) {
+	var configLogger zap.Config
+	switch format {
+	case FormatConsole:
+		configLogger = zap.NewDevelopmentConfig()
+	case FormatJSON:
+		configLogger = zap.NewProductionConfig()
+		configLogger.EncoderConfig.TimeKey = "time"
+		configLogger.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+		// журнал запросов пишет одно и то же сообщение, сэмплирование отбросило бы большую часть записей
+		configLogger.Sampling = nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+	configLogger.Level = level
 
 	logger, err := configLogger.Build()
 	if err != nil {
@@ -17,3 +39,13 @@ func InitilazerLogger() (*zap.SugaredLogger, error) {
 
 	return logger.Sugar(), err
 }
+
+// SetLevel меняет уровень логирования, уже созданные логгеры подхватывают его сразу.
+func SetLevel(text string) error {
+	parsed, err := zapcore.ParseLevel(text)
+	if err != nil {
+		return fmt.Errorf("invalid log level %q: %w", text, err)
+	}
+	level.SetLevel(parsed)
+	return nil
+}
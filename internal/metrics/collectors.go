@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const queueDepthTimeout = 2 * time.Second
+
+type poolCollector struct {
+	pool         *pgxpool.Pool
+	acquired     *prometheus.Desc
+	idle         *prometheus.Desc
+	total        *prometheus.Desc
+	max          *prometheus.Desc
+	waitCount    *prometheus.Desc
+	acquireTotal *prometheus.Desc
+}
+
+// NewPoolCollector снимает статистику pgxpool при каждом опросе /metrics.
+func NewPoolCollector(pool *pgxpool.Pool) prometheus.Collector {
+	name := func(metric string) string {
+		return prometheus.BuildFQName(namespace, "db_pool", metric)
+	}
+	return &poolCollector{
+		pool:      pool,
+		acquired:  prometheus.NewDesc(name("acquired_connections"), "Соединения, занятые запросами.", nil, nil),
+		idle:      prometheus.NewDesc(name("idle_connections"), "Свободные соединения.", nil, nil),
+		total:     prometheus.NewDesc(name("total_connections"), "Все открытые соединения.", nil, nil),
+		max:       prometheus.NewDesc(name("max_connections"), "Размер пула.", nil, nil),
+		waitCount: prometheus.NewDesc(name("empty_acquire_total"), "Получения соединения с ожиданием.", nil, nil),
+		acquireTotal: prometheus.NewDesc(
+			name("acquire_duration_seconds_total"),
+			"Суммарное время получения соединений, включая ожидание свободного.",
+			nil,
+			nil,
+		),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquired
+	ch <- c.idle
+	ch <- c.total
+	ch <- c.max
+	ch <- c.waitCount
+	ch <- c.acquireTotal
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(c.acquired, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.max, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireTotal, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+}
+
+type queueCollector struct {
+	count func(ctx context.Context) (pending, dead int64, err error)
+	depth *prometheus.Desc
+}
+
+// NewQueueCollector считает задания в таблице jobs при каждом опросе /metrics.
+func NewQueueCollector(count func(ctx context.Context) (pending, dead int64, err error)) prometheus.Collector {
+	return &queueCollector{
+		count: count,
+		depth: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "accrual", "jobs"),
+			"Задания опроса системы начислений: pending в очереди, dead в dead-letter.",
+			[]string{"state"},
+			nil,
+		),
+	}
+}
+
+func (c *queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.depth
+}
+
+func (c *queueCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), queueDepthTimeout)
+	defer cancel()
+	pending, dead, err := c.count(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.depth, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.depth, prometheus.GaugeValue, float64(pending), "pending")
+	ch <- prometheus.MustNewConstMetric(c.depth, prometheus.GaugeValue, float64(dead), "dead")
+}
+
+// NewRetryAfterGauge показывает, сколько секунд ещё длится пауза после ответа 429.
+func NewRetryAfterGauge(pausedUntil func() time.Time) prometheus.Collector {
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "accrual",
+		Name:      "retry_after_seconds",
+		Help:      "Оставшаяся пауза запросов к системе начислений после ответа 429.",
+	}, func() float64 {
+		remaining := time.Until(pausedUntil())
+		if remaining < 0 {
+			return 0
+		}
+		return remaining.Seconds()
+	})
+}
+
+// NewBreakerStateGauge показывает состояние предохранителя: 0 — замкнут, 1 — разомкнут, 2 — пробные запросы.
+func NewBreakerStateGauge(state func() int) prometheus.Collector {
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "accrual",
+		Name:      "circuit_state",
+		Help:      "Состояние предохранителя перед системой начислений: 0 — замкнут, 1 — разомкнут, 2 — пробные запросы.",
+	}, func() float64 {
+		return float64(state())
+	})
+}
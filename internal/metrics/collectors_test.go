@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueueCollector(t *testing.T) {
+	collector := NewQueueCollector(func(context.Context) (int64, int64, error) {
+		return 12, 3, nil
+	})
+
+	expected := `
+# HELP gophermart_accrual_jobs Задания опроса системы начислений: pending в очереди, dead в dead-letter.
+# TYPE gophermart_accrual_jobs gauge
+gophermart_accrual_jobs{state="dead"} 3
+gophermart_accrual_jobs{state="pending"} 12
+`
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected)))
+}
+
+func TestQueueCollector_ReportsCountError(t *testing.T) {
+	collector := NewQueueCollector(func(context.Context) (int64, int64, error) {
+		return 0, 0, errors.New("connection refused")
+	})
+
+	_, err := testutil.CollectAndLint(collector)
+
+	assert.Error(t, err)
+}
+
+func TestRetryAfterGauge(t *testing.T) {
+	tests := []struct {
+		pausedUntil time.Time
+		name        string
+		min         float64
+		max         float64
+	}{
+		{name: "paused", pausedUntil: time.Now().Add(30 * time.Second), min: 29, max: 30},
+		{name: "pause expired", pausedUntil: time.Now().Add(-time.Second), min: 0, max: 0},
+		{name: "never paused", min: 0, max: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gauge := NewRetryAfterGauge(func() time.Time { return tt.pausedUntil })
+
+			value := testutil.ToFloat64(gauge)
+
+			assert.GreaterOrEqual(t, value, tt.min)
+			assert.LessOrEqual(t, value, tt.max)
+		})
+	}
+}
+
+func TestBreakerStateGauge(t *testing.T) {
+	tests := []struct {
+		name  string
+		state int
+	}{
+		{name: "closed", state: 0},
+		{name: "open", state: 1},
+		{name: "half-open", state: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gauge := NewBreakerStateGauge(func() int { return tt.state })
+
+			assert.Equal(t, float64(tt.state), testutil.ToFloat64(gauge))
+		})
+	}
+}
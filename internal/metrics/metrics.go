@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "gophermart"
+
+// Исходы запроса к системе начислений для AccrualRequestDuration.
+const (
+	OutcomeOK              = "200"
+	OutcomeNoContent       = "204"
+	OutcomeTooManyRequests = "429"
+	OutcomeServerError     = "500"
+	OutcomeError           = "error"
+)
+
+var registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Число HTTP-запросов по маршруту и статусу ответа.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Время обработки HTTP-запроса по маршруту.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	AccrualRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "accrual",
+		Name:      "request_duration_seconds",
+		Help:      "Время запроса статуса заказа к системе начислений по исходу: 200, 204, 429, 500 или error.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"outcome"})
+
+	JobsCompleted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "accrual",
+		Name:      "jobs_completed_total",
+		Help:      "Число заданий, завершённых конечным статусом заказа.",
+	})
+
+	JobsFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "accrual",
+		Name:      "jobs_failed_total",
+		Help:      "Число неудачных попыток обработки задания по категории ошибки.",
+	}, []string{"category"})
+
+	JobsDeadLettered = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "accrual",
+		Name:      "jobs_dead_lettered_total",
+		Help:      "Число заданий, перенесённых в dead-letter после исчерпания попыток.",
+	})
+
+	PointsAccrued = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "loyalty",
+		Name:      "points_accrued_total",
+		Help:      "Сумма начисленных баллов без учёта корректировок.",
+	})
+
+	PointsCorrected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "loyalty",
+		Name:      "points_corrected_total",
+		Help:      "Сумма корректировок начислений после повторной проверки, up — доначислено, down — списано.",
+	}, []string{"direction"})
+
+	PointsWithdrawn = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "loyalty",
+		Name:      "points_withdrawn_total",
+		Help:      "Сумма списанных баллов.",
+	})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		AccrualRequestDuration,
+		JobsCompleted,
+		JobsFailed,
+		JobsDeadLettered,
+		PointsAccrued,
+		PointsCorrected,
+		PointsWithdrawn,
+	)
+}
+
+// Register добавляет сборщики, которым нужны ресурсы сервиса: пул соединений, очередь заданий, агент.
+func Register(cs ...prometheus.Collector) error {
+	for _, c := range cs {
+		if err := registry.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
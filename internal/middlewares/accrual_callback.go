@@ -0,0 +1,92 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"gophermart/internal/app/repositories"
+	"gophermart/internal/app/services/accrual"
+	"gophermart/internal/app/utils"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	maxCallbackNonceLength = 128
+	// maxCallbackBodySize с запасом вмещает ответ GET /api/orders/{number}.
+	maxCallbackBodySize = 4 << 10
+)
+
+// AccrualCallbackSignature пропускает только уведомления, подписанные общим секретом.
+// Подпись старше tolerance и повтор nonce отклоняются, поэтому перехваченное уведомление нельзя отправить повторно.
+// Если обработчик ответил 5xx, nonce освобождается и отправитель может повторить уведомление с той же подписью.
+func AccrualCallbackSignature(
+	secret string,
+	tolerance time.Duration,
+	nonceRepository repositories.NonceRepositoryInterface,
+	logger *zap.SugaredLogger,
+) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timestamp, err := strconv.ParseInt(r.Header.Get(accrual.CallbackTimestampHeader), 10, 64)
+			if err != nil {
+				http.Error(w, "", http.StatusUnauthorized)
+				return
+			}
+			nonce := r.Header.Get(accrual.CallbackNonceHeader)
+			if nonce == "" || len(nonce) > maxCallbackNonceLength {
+				http.Error(w, "", http.StatusUnauthorized)
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxCallbackBodySize))
+			if err != nil {
+				var tooLargeErr *http.MaxBytesError
+				if errors.As(err, &tooLargeErr) {
+					http.Error(w, "", http.StatusRequestEntityTooLarge)
+					return
+				}
+				http.Error(w, "", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			signature := r.Header.Get(accrual.CallbackSignatureHeader)
+			if !accrual.VerifyCallback([]byte(secret), timestamp, nonce, body, signature) {
+				http.Error(w, "", http.StatusUnauthorized)
+				return
+			}
+			skew := time.Since(time.Unix(timestamp, 0))
+			if skew > tolerance || skew < -tolerance {
+				http.Error(w, "", http.StatusUnauthorized)
+				return
+			}
+
+			// подпись принимается в окне ±tolerance, столько же nonce должен помниться
+			claimed, err := nonceRepository.Claim(r.Context(), nonce, 2*tolerance)
+			if err != nil {
+				utils.ContextLogger(r.Context(), logger).Infoln("error claim callback nonce", err)
+				http.Error(w, "", http.StatusInternalServerError)
+				return
+			}
+			if !claimed {
+				http.Error(w, "", http.StatusConflict)
+				return
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w}
+			next.ServeHTTP(recorder, r)
+
+			if recorder.status() >= http.StatusInternalServerError {
+				// уведомление не применено, повтор с тем же nonce должен пройти
+				if err = nonceRepository.Release(context.WithoutCancel(r.Context()), nonce); err != nil {
+					utils.ContextLogger(r.Context(), logger).Infoln("error release callback nonce", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package middlewares
+
+import (
+	"gophermart/internal/app/repositories/mocks"
+	"gophermart/internal/app/services/accrual"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+const (
+	testCallbackSecret    = "callback-secret"
+	testCallbackNonce     = "4f1c7a"
+	testCallbackBody      = `{"order":"12345678903","status":"PROCESSED","accrual":500}`
+	testCallbackTolerance = 5 * time.Minute
+)
+
+func newCallbackRequest(timestamp time.Time, nonce, body, signedBody string) *http.Request {
+	request := httptest.NewRequest(http.MethodPost, "/internal/accrual/callback", strings.NewReader(body))
+	request.Header.Set(accrual.CallbackTimestampHeader, strconv.FormatInt(timestamp.Unix(), 10))
+	request.Header.Set(accrual.CallbackNonceHeader, nonce)
+	request.Header.Set(accrual.CallbackSignatureHeader,
+		accrual.SignCallback([]byte(testCallbackSecret), timestamp.Unix(), nonce, []byte(signedBody)))
+	return request
+}
+
+func TestAccrualCallbackSignature(t *testing.T) {
+	tests := []struct {
+		request      *http.Request
+		name         string
+		claimed      bool
+		expectClaim  bool
+		expectedCode int
+		expectedCall int
+	}{
+		{
+			name:         "valid signature passes",
+			request:      newCallbackRequest(time.Now(), testCallbackNonce, testCallbackBody, testCallbackBody),
+			expectClaim:  true,
+			claimed:      true,
+			expectedCode: http.StatusOK,
+			expectedCall: 1,
+		},
+		{
+			name: "tampered body is rejected",
+			request: newCallbackRequest(time.Now(), testCallbackNonce,
+				strings.Replace(testCallbackBody, "500", "5000", 1), testCallbackBody),
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name: "stale timestamp is rejected",
+			request: newCallbackRequest(time.Now().Add(-testCallbackTolerance-time.Minute),
+				testCallbackNonce, testCallbackBody, testCallbackBody),
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "missing nonce is rejected",
+			request:      newCallbackRequest(time.Now(), "", testCallbackBody, testCallbackBody),
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "replayed nonce is rejected",
+			request:      newCallbackRequest(time.Now(), testCallbackNonce, testCallbackBody, testCallbackBody),
+			expectClaim:  true,
+			claimed:      false,
+			expectedCode: http.StatusConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			repo := mocks.NewMockNonceRepositoryInterface(ctrl)
+			if tt.expectClaim {
+				repo.EXPECT().Claim(gomock.Any(), testCallbackNonce, 2*testCallbackTolerance).Return(tt.claimed, nil)
+			}
+
+			calls := 0
+			handler := AccrualCallbackSignature(testCallbackSecret, testCallbackTolerance, repo, zap.NewNop().Sugar())(
+				newCountingHandler(http.StatusOK, &calls))
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, tt.request)
+
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			assert.Equal(t, tt.expectedCall, calls)
+		})
+	}
+}
+
+func TestAccrualCallbackSignature_RejectsOversizedBody(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	repo := mocks.NewMockNonceRepositoryInterface(ctrl)
+
+	body := strings.Repeat(" ", maxCallbackBodySize) + testCallbackBody
+	calls := 0
+	handler := AccrualCallbackSignature(testCallbackSecret, testCallbackTolerance, repo, zap.NewNop().Sugar())(
+		newCountingHandler(http.StatusOK, &calls))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newCallbackRequest(time.Now(), testCallbackNonce, body, body))
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	assert.Equal(t, 0, calls)
+}
+
+func TestAccrualCallbackSignature_ReleasesNonceOnServerError(t *testing.T) {
+	tests := []struct {
+		name          string
+		handlerStatus int
+		expectRelease bool
+	}{
+		{name: "server error releases nonce", handlerStatus: http.StatusInternalServerError, expectRelease: true},
+		{name: "success keeps nonce", handlerStatus: http.StatusOK},
+		{name: "client error keeps nonce", handlerStatus: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			repo := mocks.NewMockNonceRepositoryInterface(ctrl)
+			repo.EXPECT().Claim(gomock.Any(), testCallbackNonce, 2*testCallbackTolerance).Return(true, nil)
+			if tt.expectRelease {
+				repo.EXPECT().Release(gomock.Any(), testCallbackNonce).Return(nil)
+			}
+
+			calls := 0
+			handler := AccrualCallbackSignature(testCallbackSecret, testCallbackTolerance, repo, zap.NewNop().Sugar())(
+				newCountingHandler(tt.handlerStatus, &calls))
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder,
+				newCallbackRequest(time.Now(), testCallbackNonce, testCallbackBody, testCallbackBody))
+
+			assert.Equal(t, tt.handlerStatus, recorder.Code)
+			assert.Equal(t, 1, calls)
+		})
+	}
+}
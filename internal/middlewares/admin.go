@@ -0,0 +1,22 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+const adminTokenHeader = "X-Admin-Token"
+
+// AdminToken пропускает только запросы оператора с токеном из конфигурации.
+func AdminToken(token string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			provided := r.Header.Get(adminTokenHeader)
+			if provided == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				http.Error(w, "", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminToken(t *testing.T) {
+	tests := []struct {
+		name         string
+		token        string
+		expectedCode int
+		expectedCall int
+	}{
+		{name: "valid token", token: "operator", expectedCode: http.StatusOK, expectedCall: 1},
+		{name: "wrong token", token: "operator2", expectedCode: http.StatusUnauthorized},
+		{name: "missing token", token: "", expectedCode: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			handler := AdminToken("operator")(newCountingHandler(http.StatusOK, &calls))
+
+			request := httptest.NewRequest(http.MethodPost, "/internal/accrual/reverify", nil)
+			if tt.token != "" {
+				request.Header.Set(adminTokenHeader, tt.token)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			assert.Equal(t, tt.expectedCall, calls)
+		})
+	}
+}
@@ -7,9 +7,11 @@ import (
 	"net/http"
 )
 
+// Auth принимает токен доступа только открытой сессии: после выхода или отзыва сессии
+// токен перестаёт действовать, не дожидаясь истечения срока.
 func Auth(
 	jwtService services.JwtService,
-	userRepository repositories.UserRepositoryInterface,
+	sessionRepository repositories.SessionRepositoryInterface,
 ) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -19,15 +21,20 @@ func Auth(
 				return
 			}
 
-			userID, err := jwtService.GetUserID(token)
+			claims, err := jwtService.GetClaims(token)
 			if err != nil {
 				http.Error(w, "", http.StatusUnauthorized)
 				return
 			}
-			ctx := utils.SetUserID(r.Context(), userID)
+			ctx := utils.SetUserID(r.Context(), claims.UserID)
+			ctx = utils.SetSessionID(ctx, claims.SessionID)
 
-			exist := userRepository.IsExistByID(ctx, userID)
-			if !exist {
+			active, err := sessionRepository.IsActive(ctx, claims.SessionID, claims.UserID)
+			if err != nil {
+				http.Error(w, "", http.StatusInternalServerError)
+				return
+			}
+			if !active {
 				http.Error(w, "", http.StatusUnauthorized)
 				return
 			}
@@ -0,0 +1,72 @@
+package middlewares
+
+import (
+	"gophermart/internal/app/repositories/mocks"
+	"gophermart/internal/app/services"
+	"gophermart/internal/app/utils"
+	"gophermart/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuth(t *testing.T) {
+	jwtService := services.NewJwtService(&config.Config{AuthSecretKey: "test_secret", AuthTokenExpired: time.Hour})
+	token, err := jwtService.CreateJwt(testUserID, 31)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		token        string
+		active       bool
+		checkSession bool
+		expectedCode int
+		expectedCall int
+	}{
+		{
+			name:         "active session",
+			token:        token,
+			active:       true,
+			checkSession: true,
+			expectedCode: http.StatusOK,
+			expectedCall: 1,
+		},
+		{name: "revoked session", token: token, active: false, checkSession: true, expectedCode: http.StatusUnauthorized},
+		{name: "malformed token", token: "invalid.token", expectedCode: http.StatusUnauthorized},
+		{name: "missing token", expectedCode: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			repo := mocks.NewMockSessionRepositoryInterface(ctrl)
+			if tt.checkSession {
+				repo.EXPECT().IsActive(gomock.Any(), int64(31), testUserID).Return(tt.active, nil)
+			}
+
+			calls := 0
+			handler := Auth(jwtService, repo)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				sessionID, err := utils.GetSessionID(r.Context())
+				assert.NoError(t, err)
+				assert.Equal(t, int64(31), sessionID)
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			request := httptest.NewRequest(http.MethodGet, "/api/user/orders", nil)
+			if tt.token != "" {
+				request.Header.Set("Authorization", tt.token)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			assert.Equal(t, tt.expectedCall, calls)
+		})
+	}
+}
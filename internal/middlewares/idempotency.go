@@ -0,0 +1,188 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gophermart/internal/app/entities"
+	"gophermart/internal/app/repositories"
+	"gophermart/internal/app/utils"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	idempotencyKeyHeader    = "Idempotency-Key"
+	idempotencyReplayHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentBodySize ограничивает тело, которое читается в память целиком ради отпечатка запроса.
+	maxIdempotentBodySize = 64 << 10
+)
+
+// Idempotency повторяет сохранённый ответ для запроса с тем же Idempotency-Key и тем же телом.
+// Должен стоять после Auth: ключи хранятся в разрезе пользователя. Ответ 5xx или паника обработчика освобождают ключ.
+func Idempotency(
+	idempotencyRepository repositories.IdempotencyRepositoryInterface,
+	ttl time.Duration,
+	logger *zap.SugaredLogger,
+) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(idempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				http.Error(w, "", http.StatusBadRequest)
+				return
+			}
+
+			ctx := r.Context()
+			userID, err := utils.GetUserID(ctx)
+			if err != nil {
+				http.Error(w, "", http.StatusUnauthorized)
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodySize))
+			if err != nil {
+				var tooLargeErr *http.MaxBytesError
+				if errors.As(err, &tooLargeErr) {
+					http.Error(w, "", http.StatusRequestEntityTooLarge)
+					return
+				}
+				http.Error(w, "", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			idempotencyKey := &entities.IdempotencyKey{
+				UserID:      int64(userID),
+				Key:         key,
+				Fingerprint: requestFingerprint(r, body),
+			}
+			claimed, err := idempotencyRepository.Claim(ctx, idempotencyKey, ttl)
+			if err != nil {
+				utils.ContextLogger(r.Context(), logger).Infoln("error claim idempotency key", err)
+				http.Error(w, "", http.StatusInternalServerError)
+				return
+			}
+			if !claimed {
+				replayStoredResponse(w, r, idempotencyRepository, idempotencyKey, logger)
+				return
+			}
+
+			// ответ может не дойти до клиента, но результат операции должен сохраниться
+			saveCtx := context.WithoutCancel(ctx)
+			release := func() {
+				if err := idempotencyRepository.Delete(saveCtx, idempotencyKey.UserID, key); err != nil {
+					utils.ContextLogger(r.Context(), logger).Infoln("error release idempotency key", err)
+				}
+			}
+			defer func() {
+				if p := recover(); p != nil {
+					// обработчик упал, ключ не должен остаться занятым до истечения ttl
+					release()
+					panic(p)
+				}
+			}()
+
+			recorder := &responseRecorder{ResponseWriter: w}
+			next.ServeHTTP(recorder, r)
+
+			if recorder.status() >= http.StatusInternalServerError {
+				// запрос не выполнен, повтор с тем же ключом должен выполниться заново
+				release()
+				return
+			}
+			idempotencyKey.StatusCode = recorder.status()
+			idempotencyKey.ContentType = recorder.Header().Get("Content-Type")
+			idempotencyKey.ResponseBody = recorder.body.Bytes()
+			if err = idempotencyRepository.SaveResponse(saveCtx, idempotencyKey); err != nil {
+				utils.ContextLogger(r.Context(), logger).Infoln("error save idempotent response", err)
+			}
+		})
+	}
+}
+
+func replayStoredResponse(
+	w http.ResponseWriter,
+	r *http.Request,
+	idempotencyRepository repositories.IdempotencyRepositoryInterface,
+	idempotencyKey *entities.IdempotencyKey,
+	logger *zap.SugaredLogger,
+) {
+	stored, err := idempotencyRepository.GetByKey(r.Context(), idempotencyKey.UserID, idempotencyKey.Key)
+	if err != nil {
+		utils.ContextLogger(r.Context(), logger).Infoln("error get idempotency key", err)
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	if stored.Fingerprint != idempotencyKey.Fingerprint {
+		// ключ уже использован для запроса с другим телом
+		http.Error(w, "", http.StatusUnprocessableEntity)
+		return
+	}
+	if !stored.CompletedAt.Valid {
+		// первый запрос с этим ключом ещё выполняется
+		http.Error(w, "", http.StatusConflict)
+		return
+	}
+	if stored.ContentType != "" {
+		w.Header().Set("Content-Type", stored.ContentType)
+	}
+	w.Header().Set(idempotencyReplayHeader, "true")
+	w.WriteHeader(stored.StatusCode)
+	if _, err = w.Write(stored.ResponseBody); err != nil {
+		utils.ContextLogger(r.Context(), logger).Infoln("error write idempotent response", err)
+	}
+}
+
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(r.URL.Path))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	body       bytes.Buffer
+	statusCode int
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if r.statusCode == 0 {
+		r.statusCode = statusCode
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.statusCode == 0 {
+		r.statusCode = http.StatusOK
+	}
+	r.body.Write(b)
+	n, err := r.ResponseWriter.Write(b)
+	if err != nil {
+		return n, fmt.Errorf("failed to write response: %w", err)
+	}
+	return n, nil
+}
+
+func (r *responseRecorder) status() int {
+	if r.statusCode == 0 {
+		return http.StatusOK
+	}
+	return r.statusCode
+}
@@ -0,0 +1,217 @@
+package middlewares
+
+import (
+	"database/sql"
+	"errors"
+	"gophermart/internal/app/entities"
+	"gophermart/internal/app/repositories/mocks"
+	"gophermart/internal/app/utils"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+const (
+	testUserID = 7
+	testKey    = "d7c1f3c4-withdraw"
+	testBody   = `{"order":"2377225624","sum":751}`
+	testTTL    = time.Hour
+)
+
+func newIdempotencyRequest(key, body string) *http.Request {
+	request := httptest.NewRequest(http.MethodPost, "/api/user/balance/withdraw", strings.NewReader(body))
+	if key != "" {
+		request.Header.Set(idempotencyKeyHeader, key)
+	}
+	return request.WithContext(utils.SetUserID(request.Context(), testUserID))
+}
+
+func newCountingHandler(statusCode int, calls *int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		_, _ = io.ReadAll(r.Body)
+		w.WriteHeader(statusCode)
+	})
+}
+
+func TestIdempotency_WithoutKeyPassesThrough(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	repo := mocks.NewMockIdempotencyRepositoryInterface(ctrl)
+
+	calls := 0
+	handler := Idempotency(repo, testTTL, zap.NewNop().Sugar())(newCountingHandler(http.StatusOK, &calls))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newIdempotencyRequest("", testBody))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotency_StoresFirstResponse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	repo := mocks.NewMockIdempotencyRepositoryInterface(ctrl)
+
+	repo.EXPECT().Claim(gomock.Any(), gomock.Any(), testTTL).Return(true, nil)
+	repo.EXPECT().SaveResponse(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ any, key *entities.IdempotencyKey) error {
+			assert.Equal(t, int64(testUserID), key.UserID)
+			assert.Equal(t, testKey, key.Key)
+			assert.Equal(t, http.StatusPaymentRequired, key.StatusCode)
+			assert.NotEmpty(t, key.Fingerprint)
+			return nil
+		})
+
+	calls := 0
+	handler := Idempotency(repo, testTTL, zap.NewNop().Sugar())(newCountingHandler(http.StatusPaymentRequired, &calls))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newIdempotencyRequest(testKey, testBody))
+
+	assert.Equal(t, http.StatusPaymentRequired, recorder.Code)
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotency_ReplaysStoredResponse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	repo := mocks.NewMockIdempotencyRepositoryInterface(ctrl)
+
+	request := newIdempotencyRequest(testKey, testBody)
+	repo.EXPECT().Claim(gomock.Any(), gomock.Any(), testTTL).Return(false, nil)
+	repo.EXPECT().GetByKey(gomock.Any(), int64(testUserID), testKey).Return(&entities.IdempotencyKey{
+		Fingerprint: requestFingerprint(request, []byte(testBody)),
+		StatusCode:  http.StatusOK,
+		CompletedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	}, nil)
+
+	calls := 0
+	handler := Idempotency(repo, testTTL, zap.NewNop().Sugar())(newCountingHandler(http.StatusOK, &calls))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "true", recorder.Header().Get(idempotencyReplayHeader))
+	assert.Zero(t, calls)
+}
+
+func TestIdempotency_RejectsReusedKeyWithDifferentBody(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	repo := mocks.NewMockIdempotencyRepositoryInterface(ctrl)
+
+	repo.EXPECT().Claim(gomock.Any(), gomock.Any(), testTTL).Return(false, nil)
+	repo.EXPECT().GetByKey(gomock.Any(), int64(testUserID), testKey).Return(&entities.IdempotencyKey{
+		Fingerprint: "fingerprint-of-another-body",
+		StatusCode:  http.StatusOK,
+		CompletedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	}, nil)
+
+	calls := 0
+	handler := Idempotency(repo, testTTL, zap.NewNop().Sugar())(newCountingHandler(http.StatusOK, &calls))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newIdempotencyRequest(testKey, testBody))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Zero(t, calls)
+}
+
+func TestIdempotency_ConflictWhileFirstRequestInProgress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	repo := mocks.NewMockIdempotencyRepositoryInterface(ctrl)
+
+	request := newIdempotencyRequest(testKey, testBody)
+	repo.EXPECT().Claim(gomock.Any(), gomock.Any(), testTTL).Return(false, nil)
+	repo.EXPECT().GetByKey(gomock.Any(), int64(testUserID), testKey).Return(&entities.IdempotencyKey{
+		Fingerprint: requestFingerprint(request, []byte(testBody)),
+	}, nil)
+
+	calls := 0
+	handler := Idempotency(repo, testTTL, zap.NewNop().Sugar())(newCountingHandler(http.StatusOK, &calls))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+	assert.Zero(t, calls)
+}
+
+func TestIdempotency_ReleasesKeyOnServerError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	repo := mocks.NewMockIdempotencyRepositoryInterface(ctrl)
+
+	repo.EXPECT().Claim(gomock.Any(), gomock.Any(), testTTL).Return(true, nil)
+	repo.EXPECT().Delete(gomock.Any(), int64(testUserID), testKey).Return(nil)
+
+	calls := 0
+	handler := Idempotency(repo, testTTL, zap.NewNop().Sugar())(newCountingHandler(http.StatusInternalServerError, &calls))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newIdempotencyRequest(testKey, testBody))
+
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotency_ReleasesKeyOnPanic(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	repo := mocks.NewMockIdempotencyRepositoryInterface(ctrl)
+
+	repo.EXPECT().Claim(gomock.Any(), gomock.Any(), testTTL).Return(true, nil)
+	repo.EXPECT().Delete(gomock.Any(), int64(testUserID), testKey).Return(nil)
+
+	handler := Idempotency(repo, testTTL, zap.NewNop().Sugar())(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("withdraw failed")
+	}))
+
+	assert.PanicsWithValue(t, "withdraw failed", func() {
+		handler.ServeHTTP(httptest.NewRecorder(), newIdempotencyRequest(testKey, testBody))
+	})
+}
+
+func TestIdempotency_ClaimFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	repo := mocks.NewMockIdempotencyRepositoryInterface(ctrl)
+
+	repo.EXPECT().Claim(gomock.Any(), gomock.Any(), testTTL).Return(false, errors.New("connection refused"))
+
+	calls := 0
+	handler := Idempotency(repo, testTTL, zap.NewNop().Sugar())(newCountingHandler(http.StatusOK, &calls))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newIdempotencyRequest(testKey, testBody))
+
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Zero(t, calls)
+}
+
+func TestIdempotency_RejectsOversizedBody(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	// ключ не занимается, поэтому репозиторий не вызывается
+	repo := mocks.NewMockIdempotencyRepositoryInterface(ctrl)
+
+	calls := 0
+	handler := Idempotency(repo, testTTL, zap.NewNop().Sugar())(newCountingHandler(http.StatusOK, &calls))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newIdempotencyRequest(testKey, strings.Repeat(" ", maxIdempotentBodySize+1)))
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	assert.Zero(t, calls)
+}
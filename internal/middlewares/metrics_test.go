@@ -0,0 +1,30 @@
+package middlewares
+
+import (
+	"gophermart/internal/metrics"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics_CountsByRoutePattern(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(Metrics())
+	router.Get("/api/user/orders/{number}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	requests := metrics.HTTPRequests.WithLabelValues(http.MethodGet, "/api/user/orders/{number}", "204")
+	notFound := metrics.HTTPRequests.WithLabelValues(http.MethodGet, "unmatched", "404")
+	before, notFoundBefore := testutil.ToFloat64(requests), testutil.ToFloat64(notFound)
+
+	for _, path := range []string{"/api/user/orders/1", "/api/user/orders/2", "/unknown"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, http.NoBody))
+	}
+
+	assert.Equal(t, before+2, testutil.ToFloat64(requests))
+	assert.Equal(t, notFoundBefore+1, testutil.ToFloat64(notFound))
+}
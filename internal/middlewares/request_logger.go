@@ -0,0 +1,95 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"gophermart/internal/app/utils"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	RequestIDHeader    = "X-Request-ID"
+	maxRequestIDLength = 128
+	requestIDBytes     = 16
+)
+
+// RequestLogger пишет по записи на каждый запрос и передаёт X-Request-ID в контекст, чтобы логи
+// обработчиков и сервисов можно было связать с запросом. Идентификатор клиента сохраняется, если он допустим.
+func RequestLogger(logger *zap.SugaredLogger) func(next http.Handler) http.Handler {
+	requestLogger := logger.With("component:RequestLogger", "RequestLogger")
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			requestID := r.Header.Get(RequestIDHeader)
+			if !validRequestID(requestID) {
+				requestID = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, requestID)
+
+			ctx := utils.SetRequestID(r.Context(), requestID)
+			ctx, userIDHolder := utils.WithUserIDHolder(ctx)
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			fields := []any{
+				"request_id", requestID,
+				"method", r.Method,
+				"route", routePattern(r),
+				"status", status,
+				"latency", time.Since(start),
+				"bytes", ww.BytesWritten(),
+			}
+			if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+				fields = append(fields, "trace_id", spanContext.TraceID().String())
+			}
+			if userID, ok := userIDHolder.UserID(); ok {
+				fields = append(fields, "user_id", userID)
+			}
+			if status >= http.StatusInternalServerError {
+				requestLogger.Errorw("request", fields...)
+				return
+			}
+			requestLogger.Infow("request", fields...)
+		})
+	}
+}
+
+// routePattern возвращает шаблон маршрута, а не путь, чтобы номера заказов не попадали в журнал запросов.
+func routePattern(r *http.Request) string {
+	if routeContext := chi.RouteContext(r.Context()); routeContext != nil {
+		if pattern := routeContext.RoutePattern(); pattern != "" {
+			return pattern
+		}
+	}
+	return "unmatched"
+}
+
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range requestID {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	raw := make([]byte, requestIDBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(raw)
+}
@@ -0,0 +1,98 @@
+package middlewares
+
+import (
+	"gophermart/internal/app/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newLoggedRouter(t *testing.T, status int) (*chi.Mux, *observer.ObservedLogs, *string) {
+	t.Helper()
+	core, logs := observer.New(zapcore.InfoLevel)
+	var handlerRequestID string
+
+	router := chi.NewRouter()
+	router.Use(RequestLogger(zap.New(core).Sugar()))
+	router.With(func(next http.Handler) http.Handler {
+		// как Auth: пользователь становится известен глубже по цепочке middleware
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(utils.SetUserID(r.Context(), testUserID)))
+		})
+	}).Get("/api/user/orders/{number}", func(w http.ResponseWriter, r *http.Request) {
+		handlerRequestID = utils.GetRequestID(r.Context())
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("body"))
+	})
+	return router, logs, &handlerRequestID
+}
+
+func TestRequestLogger_PropagatesRequestID(t *testing.T) {
+	router, logs, handlerRequestID := newLoggedRouter(t, http.StatusOK)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/user/orders/12345678903", http.NoBody)
+	request.Header.Set(RequestIDHeader, "client-request-1")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, "client-request-1", recorder.Header().Get(RequestIDHeader))
+	assert.Equal(t, "client-request-1", *handlerRequestID)
+	require.Equal(t, 1, logs.Len())
+	entry := logs.All()[0]
+	assert.Equal(t, zapcore.InfoLevel, entry.Level)
+	fields := entry.ContextMap()
+	assert.Equal(t, "client-request-1", fields["request_id"])
+	assert.Equal(t, http.MethodGet, fields["method"])
+	assert.Equal(t, "/api/user/orders/{number}", fields["route"])
+	assert.EqualValues(t, http.StatusOK, fields["status"])
+	assert.EqualValues(t, len("body"), fields["bytes"])
+	assert.EqualValues(t, testUserID, fields["user_id"])
+	assert.Contains(t, fields, "latency")
+}
+
+func TestRequestLogger_GeneratesRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+	}{
+		{name: "missing", incoming: ""},
+		{name: "with spaces", incoming: "not a valid id"},
+		{name: "too long", incoming: strings.Repeat("a", maxRequestIDLength+1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, logs, handlerRequestID := newLoggedRouter(t, http.StatusOK)
+
+			request := httptest.NewRequest(http.MethodGet, "/api/user/orders/12345678903", http.NoBody)
+			if tt.incoming != "" {
+				request.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			requestID := recorder.Header().Get(RequestIDHeader)
+			assert.Len(t, requestID, 2*requestIDBytes)
+			assert.Equal(t, requestID, *handlerRequestID)
+			require.Equal(t, 1, logs.Len())
+			assert.Equal(t, requestID, logs.All()[0].ContextMap()["request_id"])
+		})
+	}
+}
+
+func TestRequestLogger_ServerErrorLoggedAsError(t *testing.T) {
+	router, logs, _ := newLoggedRouter(t, http.StatusInternalServerError)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/user/orders/1", http.NoBody))
+
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, zapcore.ErrorLevel, logs.All()[0].Level)
+}
@@ -0,0 +1,32 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const metricsPath = "/metrics"
+
+// Tracing открывает span на каждый запрос и продолжает трассу из входящего traceparent.
+// Имя span — шаблон маршрута, а не путь; маршрут известен только после разбора, поэтому имя
+// задаётся после ответа. Опросы Prometheus не трассируются.
+func Tracing() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		named := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+
+			route := routePattern(r)
+			span := trace.SpanFromContext(r.Context())
+			span.SetName(r.Method + " " + route)
+			span.SetAttributes(semconv.HTTPRoute(route))
+		})
+		return otelhttp.NewHandler(named, "http.request",
+			otelhttp.WithFilter(func(r *http.Request) bool {
+				return r.URL.Path != metricsPath
+			}),
+		)
+	}
+}
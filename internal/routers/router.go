@@ -1,17 +1,17 @@
 package routers
 
 import (
+	"fmt"
 	"gophermart/internal/app/handlers"
 	"gophermart/internal/app/repositories"
 	"gophermart/internal/app/services"
 	"gophermart/internal/config"
+	"gophermart/internal/metrics"
 	"gophermart/internal/middlewares"
 	"net/http"
 
 	"go.uber.org/zap"
 
-	"github.com/go-chi/chi/v5/middleware"
-
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -19,52 +19,107 @@ import (
 func ConfigureServerHandler(
 	db *pgxpool.Pool,
 	cfg *config.Config,
+	reloader handlers.ConfigReloader,
 	logger *zap.SugaredLogger,
-) http.Handler {
+) (http.Handler, error) {
 	router := chi.NewRouter()
 
-	router.Use(middleware.Logger)
+	router.Use(middlewares.Tracing())
+	router.Use(middlewares.RequestLogger(logger))
+	router.Use(middlewares.Metrics())
 
-	registerAPIRouter(router, db, cfg, logger)
+	if err := registerAPIRouter(router, db, cfg, reloader, logger); err != nil {
+		return nil, err
+	}
 
 	router.NotFound(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
 	})
 
-	return router
+	return router, nil
 }
 
 func registerAPIRouter(
 	r *chi.Mux,
 	db *pgxpool.Pool,
 	cfg *config.Config,
+	reloader handlers.ConfigReloader,
 	logger *zap.SugaredLogger,
-) {
+) error {
+	jwtKeys, err := services.LoadKeySet(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to load jwt keys: %w", err)
+	}
+
 	userRepo := repositories.NewUserRepository(db)
 	orderRepo := repositories.NewOrderRepository(db)
 	withdrawRepo := repositories.NewWithdrawRepository(db)
 	jobRepo := repositories.NewJobRepository(db)
+	balanceEntryRepo := repositories.NewBalanceEntryRepository(db)
+	idempotencyRepo := repositories.NewIdempotencyRepository(db)
+	sessionRepo := repositories.NewSessionRepository(db)
 
 	userService := services.NewUserService(userRepo)
 	orderService := services.NewOrderService(db, orderRepo, jobRepo)
-	balanceService := services.NewBalanceService(db, userRepo, orderRepo, withdrawRepo)
-	jwtService := services.NewJwtService(cfg)
+	balanceService := services.NewBalanceService(db, userRepo, orderRepo, withdrawRepo, balanceEntryRepo)
+	jwtService := services.NewJwtServiceWithKeys(cfg, jwtKeys)
+	sessionService := services.NewSessionService(db, sessionRepo, jwtService, cfg)
 
-	userHandler := handlers.NewUserHandler(userService, jwtService, logger)
+	userHandler := handlers.NewUserHandler(userService, sessionService, logger)
+	jwksHandler := handlers.NewJwksHandler(jwtService, logger)
 	orderHandler := handlers.NewOrderHandler(orderService, logger)
 	balanceHandler := handlers.NewBalanceHandler(balanceService, logger)
 
+	r.Get("/.well-known/jwks.json", jwksHandler.GetJwks())
 	r.Route("/api/user", func(r chi.Router) {
 		r.Post("/register", userHandler.RegisterUser())
 		r.Post("/login", userHandler.LoginUser())
+		r.Post("/token/refresh", userHandler.RefreshToken())
 		r.Group(func(r chi.Router) {
-			r.Use(middlewares.Auth(jwtService, userRepo))
+			r.Use(middlewares.Auth(jwtService, sessionRepo))
+			r.Post("/logout", userHandler.Logout())
 			r.Post("/orders", orderHandler.StoreOrders())
 			r.Get("/orders", orderHandler.GetUserOrders())
 
 			r.Get("/balance", balanceHandler.GetUserBalance())
-			r.Post("/balance/withdraw", balanceHandler.StoreBalanceWithdraw())
+			r.With(middlewares.Idempotency(idempotencyRepo, cfg.IdempotencyKeyTTL, logger)).
+				Post("/balance/withdraw", balanceHandler.StoreBalanceWithdraw())
 			r.Get("/withdrawals", balanceHandler.GetWithdrawals())
 		})
 	})
+
+	accrualCallbackService := services.NewAccrualCallbackService(
+		db,
+		jobRepo,
+		orderRepo,
+		userRepo,
+		balanceEntryRepo,
+		cfg,
+		logger,
+	)
+
+	// без секрета уведомления не принимаются, статусы заказов узнаются только опросом
+	if cfg.AccrualCallbackSecret != "" {
+		callbackHandler := handlers.NewAccrualCallbackHandler(accrualCallbackService, logger)
+		signature := middlewares.AccrualCallbackSignature(
+			cfg.AccrualCallbackSecret,
+			cfg.AccrualCallbackTolerance,
+			repositories.NewNonceRepository(db),
+			logger,
+		)
+		r.With(signature).Post("/internal/accrual/callback", callbackHandler.ApplyCallback())
+	}
+
+	if cfg.AdminToken != "" {
+		reverifyHandler := handlers.NewAccrualReverifyHandler(accrualCallbackService, logger)
+		configReloadHandler := handlers.NewConfigReloadHandler(reloader, logger)
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.AdminToken(cfg.AdminToken))
+			r.Method(http.MethodGet, "/metrics", metrics.Handler())
+			r.Post("/internal/accrual/reverify", reverifyHandler.Reverify())
+			r.Post("/internal/config/reload", configReloadHandler.Reload())
+		})
+	}
+
+	return nil
 }
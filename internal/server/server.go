@@ -1,6 +1,8 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"gophermart/internal/config"
 	"gophermart/internal/routers"
@@ -12,15 +14,44 @@ import (
 )
 
 func ConfigureServerHandler(
+	ctx context.Context,
 	db *pgxpool.Pool,
 	cfg *config.Config,
+	reloader *config.Reloader,
 	logger *zap.SugaredLogger,
 ) error {
-	router := routers.ConfigureServerHandler(db, cfg, logger)
-	logger.Infoln("Start http server: ", cfg.HTTPAddress)
-	err := http.ListenAndServe(cfg.HTTPAddress, router)
+	router, err := routers.ConfigureServerHandler(db, cfg, reloader, logger)
 	if err != nil {
-		return fmt.Errorf("failed to start server: %w", err)
+		return fmt.Errorf("failed to configure router: %w", err)
+	}
+	srv := &http.Server{
+		Addr:    cfg.HTTPAddress,
+		Handler: router,
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Infoln("Start http server: ", cfg.HTTPAddress)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+		close(serverErr)
+	}()
+
+	select {
+	case err := <-serverErr:
+		if err != nil {
+			return fmt.Errorf("failed to start server: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	logger.Infoln("Shutting down http server, timeout:", cfg.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shutdown server: %w", err)
 	}
 	return nil
 }
@@ -42,7 +42,12 @@ func NewDB(ctx context.Context, dsn string) (*DB, error) {
 	if err := runMigrations(dsn); err != nil {
 		return nil, fmt.Errorf("failed to run DB migrations: %w", err)
 	}
-	pool, err := pgxpool.New(ctx, dsn)
+	poolConfig, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse a connection string: %w", err)
+	}
+	poolConfig.ConnConfig.Tracer = newQueryTracer()
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create a connection pool: %w", err)
 	}
@@ -50,3 +55,7 @@ func NewDB(ctx context.Context, dsn string) (*DB, error) {
 		Pool: pool,
 	}, nil
 }
+
+func (db *DB) Close() {
+	db.Pool.Close()
+}
@@ -0,0 +1,8 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE users ALTER COLUMN balance DROP NOT NULL;
+ALTER TABLE users ALTER COLUMN balance DROP DEFAULT;
+
+DROP TABLE balance_entries;
+
+COMMIT;
\ No newline at end of file
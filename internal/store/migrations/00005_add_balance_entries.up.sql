@@ -0,0 +1,45 @@
+BEGIN TRANSACTION;
+
+CREATE TABLE IF NOT EXISTS balance_entries (
+    id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    user_id INT NOT NULL,
+    type_id INT NOT NULL,
+    amount FLOAT NOT NULL,
+    order_id BIGINT NULL,
+    withdraw_id BIGINT NULL,
+    reason VARCHAR(255) NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    CONSTRAINT fk_balance_entries_user FOREIGN KEY (user_id) REFERENCES users(id),
+    CONSTRAINT fk_balance_entries_order FOREIGN KEY (order_id) REFERENCES orders(id),
+    CONSTRAINT fk_balance_entries_withdraw FOREIGN KEY (withdraw_id) REFERENCES withdraws(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_balance_entries_user_id ON balance_entries (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_balance_entries_accrual_order ON balance_entries (order_id) WHERE type_id = 1;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_balance_entries_withdraw ON balance_entries (withdraw_id) WHERE type_id = 2;
+
+INSERT INTO balance_entries (user_id, type_id, amount, order_id, created_at)
+SELECT user_id, 1, accrual, id, updated_at
+FROM orders
+WHERE accrual > 0;
+
+INSERT INTO balance_entries (user_id, type_id, amount, withdraw_id, created_at)
+SELECT user_id, 2, -withdraw, id, created_at
+FROM withdraws
+WHERE withdraw > 0;
+
+INSERT INTO balance_entries (user_id, type_id, amount, reason)
+SELECT u.id, 3, COALESCE(u.balance, 0) - COALESCE(l.total, 0), 'opening balance carried over from users.balance'
+FROM users u
+LEFT JOIN (
+    SELECT user_id, SUM(amount) AS total
+    FROM balance_entries
+    GROUP BY user_id
+) l ON l.user_id = u.id
+WHERE COALESCE(u.balance, 0) <> COALESCE(l.total, 0);
+
+UPDATE users SET balance = 0 WHERE balance IS NULL;
+ALTER TABLE users ALTER COLUMN balance SET DEFAULT 0;
+ALTER TABLE users ALTER COLUMN balance SET NOT NULL;
+
+COMMIT;
\ No newline at end of file
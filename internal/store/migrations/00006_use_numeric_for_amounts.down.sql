@@ -0,0 +1,8 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE users ALTER COLUMN balance TYPE FLOAT USING balance::float;
+ALTER TABLE orders ALTER COLUMN accrual TYPE FLOAT USING accrual::float;
+ALTER TABLE withdraws ALTER COLUMN withdraw TYPE FLOAT USING withdraw::float;
+ALTER TABLE balance_entries ALTER COLUMN amount TYPE FLOAT USING amount::float;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,15 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE users ALTER COLUMN balance TYPE NUMERIC(16, 2) USING round(balance::numeric, 2);
+ALTER TABLE orders ALTER COLUMN accrual TYPE NUMERIC(16, 2) USING round(accrual::numeric, 2);
+ALTER TABLE withdraws ALTER COLUMN withdraw TYPE NUMERIC(16, 2) USING round(withdraw::numeric, 2);
+ALTER TABLE balance_entries ALTER COLUMN amount TYPE NUMERIC(16, 2) USING round(amount::numeric, 2);
+
+UPDATE users u
+SET balance = COALESCE((
+    SELECT SUM(e.amount)
+    FROM balance_entries e
+    WHERE e.user_id = u.id
+), 0);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,16 @@
+BEGIN TRANSACTION;
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id INT NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    fingerprint VARCHAR(64) NOT NULL,
+    status_code INT NULL,
+    content_type VARCHAR(255) NULL,
+    response_body BYTEA NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    completed_at TIMESTAMP NULL,
+    PRIMARY KEY (user_id, idempotency_key),
+    CONSTRAINT fk_idempotency_keys_user FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,8 @@
+BEGIN TRANSACTION;
+
+DROP INDEX IF EXISTS idx_jobs_locked_until;
+
+ALTER TABLE jobs DROP COLUMN IF EXISTS worker_id;
+ALTER TABLE jobs DROP COLUMN IF EXISTS locked_until;
+
+COMMIT;
\ No newline at end of file
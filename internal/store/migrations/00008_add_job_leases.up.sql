@@ -0,0 +1,8 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP NULL;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS worker_id VARCHAR(255) NULL;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_locked_until ON jobs (locked_until);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,10 @@
+BEGIN TRANSACTION;
+
+DROP INDEX IF EXISTS idx_jobs_next_attempt_at;
+
+ALTER TABLE jobs DROP COLUMN IF EXISTS dead_at;
+ALTER TABLE jobs DROP COLUMN IF EXISTS last_error;
+ALTER TABLE jobs DROP COLUMN IF EXISTS next_attempt_at;
+ALTER TABLE jobs DROP COLUMN IF EXISTS attempts;
+
+COMMIT;
\ No newline at end of file
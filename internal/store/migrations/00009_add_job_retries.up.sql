@@ -0,0 +1,10 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMP NULL;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS last_error TEXT NULL;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS dead_at TIMESTAMP NULL;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_next_attempt_at ON jobs (next_attempt_at) WHERE dead_at IS NULL;
+
+COMMIT;
\ No newline at end of file
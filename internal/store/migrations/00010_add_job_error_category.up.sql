@@ -0,0 +1,5 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS last_error_category VARCHAR(32) NULL;
+
+COMMIT;
\ No newline at end of file
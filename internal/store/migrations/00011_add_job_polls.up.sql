@@ -0,0 +1,5 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS polls INTEGER NOT NULL DEFAULT 0;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,5 @@
+BEGIN TRANSACTION;
+
+DROP TABLE IF EXISTS callback_nonces;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,8 @@
+BEGIN TRANSACTION;
+
+CREATE TABLE IF NOT EXISTS callback_nonces (
+    nonce VARCHAR(128) PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+COMMIT;
\ No newline at end of file
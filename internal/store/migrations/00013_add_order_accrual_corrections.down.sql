@@ -0,0 +1,6 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS accrual_corrected_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS previous_accrual;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,6 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS previous_accrual NUMERIC(16, 2) NULL;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS accrual_corrected_at TIMESTAMP NULL;
+
+COMMIT;
\ No newline at end of file
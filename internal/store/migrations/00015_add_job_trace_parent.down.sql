@@ -0,0 +1,5 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE jobs DROP COLUMN IF EXISTS trace_parent;
+
+COMMIT;
\ No newline at end of file
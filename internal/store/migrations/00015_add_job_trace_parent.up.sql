@@ -0,0 +1,5 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS trace_parent VARCHAR(55) NULL;
+
+COMMIT;
\ No newline at end of file
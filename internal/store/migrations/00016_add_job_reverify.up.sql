@@ -0,0 +1,5 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS reverify BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,54 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"gophermart/internal/tracing"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// queryTracer пишет span на каждый запрос к базе, в том числе BEGIN и COMMIT, чтобы в трассе была видна
+// работа репозиториев. Аргументы запроса в span не попадают: среди них бывают хеши паролей и токенов.
+type queryTracer struct {
+	tracer trace.Tracer
+}
+
+func newQueryTracer() *queryTracer {
+	return &queryTracer{tracer: otel.Tracer("gophermart/internal/store")}
+}
+
+func (t *queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	operation := queryOperation(data.SQL)
+	ctx, _ = t.tracer.Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(data.SQL),
+		),
+	)
+	return ctx
+}
+
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	err := data.Err
+	if errors.Is(err, pgx.ErrNoRows) {
+		// отсутствие строки — обычный ответ репозитория, а не сбой базы
+		err = nil
+	}
+	tracing.End(trace.SpanFromContext(ctx), err)
+}
+
+// queryOperation возвращает первое слово запроса: SELECT, UPDATE, BEGIN и т. п.
+func queryOperation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "query"
+	}
+	return strings.ToUpper(fields[0])
+}
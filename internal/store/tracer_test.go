@@ -0,0 +1,69 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+func TestQueryTracer(t *testing.T) {
+	tests := []struct {
+		name       string
+		sql        string
+		err        error
+		wantName   string
+		wantStatus codes.Code
+	}{
+		{
+			name:       "select",
+			sql:        "\n\t\tSELECT id FROM orders WHERE order_id = $1\n\t",
+			wantName:   "SELECT",
+			wantStatus: codes.Unset,
+		},
+		{
+			name:       "no rows is not an error",
+			sql:        "select id from users where login = $1",
+			err:        pgx.ErrNoRows,
+			wantName:   "SELECT",
+			wantStatus: codes.Unset,
+		},
+		{
+			name:       "failed query",
+			sql:        "UPDATE jobs SET attempts = $1 WHERE id = $2",
+			err:        errors.New("deadlock detected"),
+			wantName:   "UPDATE",
+			wantStatus: codes.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			tracer := &queryTracer{
+				tracer: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test"),
+			}
+
+			ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{
+				SQL:  tt.sql,
+				Args: []any{"secret"},
+			})
+			tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: tt.err})
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tt.wantName, spans[0].Name())
+			assert.Equal(t, tt.wantStatus, spans[0].Status().Code)
+			assert.Contains(t, spans[0].Attributes(), semconv.DBQueryText(tt.sql))
+			for _, attr := range spans[0].Attributes() {
+				assert.NotEqual(t, "secret", attr.Value.Emit())
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTraceParent_RoundTripsThroughLinks(t *testing.T) {
+	provider := sdktrace.NewTracerProvider()
+	ctx, span := provider.Tracer("test").Start(context.Background(), "upload")
+	defer span.End()
+
+	traceParent := TraceParent(ctx)
+
+	require.NotNil(t, traceParent)
+	links := Links(traceParent)
+	require.Len(t, links, 1)
+	assert.Equal(t, span.SpanContext().TraceID(), links[0].SpanContext.TraceID())
+	assert.Equal(t, span.SpanContext().SpanID(), links[0].SpanContext.SpanID())
+}
+
+func TestTraceParent_WithoutSpan(t *testing.T) {
+	assert.Nil(t, TraceParent(context.Background()))
+}
+
+func TestLinks_IgnoresMissingOrMalformedTraceParent(t *testing.T) {
+	malformed := "00-not-a-trace-01"
+
+	assert.Empty(t, Links(nil))
+	assert.Empty(t, Links(&malformed))
+}
+
+func TestEnd_RecordsError(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	_, failed := tracer.Start(context.Background(), "failed")
+	End(failed, errors.New("accrual system unavailable"))
+	_, succeeded := tracer.Start(context.Background(), "succeeded")
+	End(succeeded, nil)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "accrual system unavailable", spans[0].Status().Description)
+	assert.Len(t, spans[0].Events(), 1)
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+}